@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"bereths.com/netstar/users"
+)
+
+// what the login and register forms are rendered with
+type AccountForm struct {
+	Username string
+	Next     string
+	// a message id or the text of an error without a message
+	Error string
+}
+
+// shows the login form
+func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
+	render(w, r, login, &AccountForm{Next: users.SafeRedirect(r.URL.Query().Get("next"))})
+}
+
+// logs a user in and redirects to where the user came from
+func LoginHandler(manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		form := &AccountForm{Username: r.PostFormValue("username"), Next: users.SafeRedirect(r.PostFormValue("next"))}
+
+		user, err := manager.Store.Authenticate(form.Username, r.PostFormValue("password"))
+		if err == users.ErrInvalidCredentials {
+			form.Error = errorMessage(err)
+			w.WriteHeader(http.StatusUnauthorized)
+			render(w, r, login, form)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := manager.Login(w, user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("User logged in: ", user.Username)
+		http.Redirect(w, r, form.Next, http.StatusSeeOther)
+	}
+}
+
+// shows the registration form
+func RegisterPageHandler(w http.ResponseWriter, r *http.Request) {
+	render(w, r, register, &AccountForm{Next: users.SafeRedirect(r.URL.Query().Get("next"))})
+}
+
+// creates a new user and logs it in
+func RegisterHandler(manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		form := &AccountForm{Username: r.PostFormValue("username"), Next: users.SafeRedirect(r.PostFormValue("next"))}
+
+		if r.PostFormValue("password") != r.PostFormValue("password_confirmation") {
+			form.Error = "error.passwords_mismatch"
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, register, form)
+			return
+		}
+
+		user, err := manager.Store.Register(form.Username, r.PostFormValue("password"))
+		if err != nil {
+			form.Error = errorMessage(err)
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, register, form)
+			return
+		}
+
+		if err := manager.Login(w, user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("User registered: ", user.Username)
+		http.Redirect(w, r, form.Next, http.StatusSeeOther)
+	}
+}
+
+// logs the current user out
+func LogoutHandler(manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := manager.Logout(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/notify"
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+// opens a database in a temporary directory
+func GetTestDB(t *testing.T) *bolt.DB {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// starts netstar with accounts and returns a browser like client keeping cookies
+func GetAccountServer(t *testing.T, themoviedbAPI *themoviedb.Client) (*httptest.Server, *http.Client, *App) {
+	db := GetTestDB(t)
+	userStore, err := users.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wl, err := watchlist.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := progress.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifyStore, err := notify.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ratingStore, err := ratings.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{
+		TMDB:      themoviedbAPI,
+		Users:     users.NewManager(userStore, false),
+		Watchlist: wl,
+		Progress:  pr,
+		Notify:    notifyStore,
+		Ratings:   ratingStore,
+	}
+	mockServer := httptest.NewServer(NewAppRouter(app))
+	t.Cleanup(mockServer.Close)
+
+	jar, _ := cookiejar.New(nil)
+	return mockServer, &http.Client{Jar: jar}, app
+}
+
+// registers jonas and logs the client in
+func LoginTestUser(t *testing.T, mockServer *httptest.Server, client *http.Client) {
+	token := GetCSRFToken(t, client, mockServer.URL+"/register")
+	resp, err := client.PostForm(mockServer.URL+"/register", url.Values{
+		"csrf_token":            {token},
+		"username":              {"Jonas"},
+		"password":              {"winter is coming"},
+		"password_confirmation": {"winter is coming"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+var csrfInput = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// loads page and returns the csrf token of its forms
+func GetCSRFToken(t *testing.T, client *http.Client, page string) string {
+	resp, err := client.Get(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	m := csrfInput.FindSubmatch(body)
+	if m == nil {
+		t.Fatalf("no csrf token on %s", page)
+	}
+	return string(m[1])
+}
+
+func ReadBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestRegisterLoginAndLogout(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+
+	token := GetCSRFToken(t, client, mockServer.URL+"/register")
+	resp, err := client.PostForm(mockServer.URL+"/register", url.Values{
+		"csrf_token":            {token},
+		"username":              {"Jonas"},
+		"password":              {"winter is coming"},
+		"password_confirmation": {"winter is coming"},
+		"next":                  {"/login"},
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, ReadBody(t, resp), "Log out", "registered user should be logged in")
+
+	token = GetCSRFToken(t, client, mockServer.URL+"/login")
+	resp, _ = client.PostForm(mockServer.URL+"/logout", url.Values{"csrf_token": {token}})
+	assert.NotContains(t, ReadBody(t, resp), "Log out")
+
+	token = GetCSRFToken(t, client, mockServer.URL+"/login")
+	resp, _ = client.PostForm(mockServer.URL+"/login", url.Values{"csrf_token": {token}, "username": {"jonas"}, "password": {"wrong password"}})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "invalid username or password")
+
+	resp, _ = client.PostForm(mockServer.URL+"/login", url.Values{"csrf_token": {token}, "username": {"jonas"}, "password": {"winter is coming"}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "Jonas")
+}
+
+func TestRegisterWithInvalidForm(t *testing.T) {
+
+	mockServer, client, app := GetAccountServer(t, GetValidClient())
+	store := app.Users.Store
+	token := GetCSRFToken(t, client, mockServer.URL+"/register")
+
+	resp, _ := client.PostForm(mockServer.URL+"/register", url.Values{
+		"csrf_token": {token}, "username": {"Jonas"}, "password": {"winter is coming"}, "password_confirmation": {"typo"},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "passwords do not match")
+
+	all, _ := store.All()
+	assert.Empty(t, all)
+}
+
+func TestLoginWithoutCSRFToken(t *testing.T) {
+
+	mockServer, client, app := GetAccountServer(t, GetValidClient())
+	store := app.Users.Store
+	store.Register("Jonas", "winter is coming")
+
+	resp, _ := client.PostForm(mockServer.URL+"/login", url.Values{"username": {"Jonas"}, "password": {"winter is coming"}})
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
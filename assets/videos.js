@@ -0,0 +1,11 @@
+// third party players are only loaded once a video is clicked
+document.querySelectorAll(".video-embed").forEach(function(embed) {
+	embed.querySelector(".video-play").addEventListener("click", function() {
+		const frame = document.createElement("iframe");
+		frame.src = embed.dataset.src;
+		frame.allow = "autoplay; fullscreen; picture-in-picture";
+		frame.allowFullscreen = true;
+		frame.className = "video-frame";
+		embed.replaceChildren(frame);
+	});
+});
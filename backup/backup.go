@@ -0,0 +1,200 @@
+// Package backup exports the watchlist, watched episodes and ratings of a user
+// and restores them again, on the same or another netstar.
+package backup
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/watchlist"
+)
+
+// the version of the export format
+const Version = 1
+
+// everything netstar knows about a user
+type Data struct {
+	Version   int               `json:"version"`
+	Username  string            `json:"username"`
+	Exported  time.Time         `json:"exported"`
+	Watchlist []watchlist.Entry `json:"watchlist"`
+	Watched   []Watched         `json:"watched"`
+	Ratings   []Rating          `json:"ratings"`
+}
+
+// a watched episode
+type Watched struct {
+	ShowID    int       `json:"show_id"`
+	Name      string    `json:"name"`
+	Season    int       `json:"season"`
+	Episode   int       `json:"episode"`
+	WatchedAt time.Time `json:"watched_at"`
+}
+
+// a rating with its review, the user is left out
+type Rating struct {
+	Kind    string    `json:"kind"`
+	ShowID  int       `json:"show_id"`
+	Season  int       `json:"season,omitempty"`
+	Episode int       `json:"episode,omitempty"`
+	Name    string    `json:"name"`
+	Rating  int       `json:"rating"`
+	Text    string    `json:"text,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+func (r Rating) target() ratings.Target {
+	return ratings.Target{Kind: r.Kind, ShowID: r.ShowID, Season: r.Season, Episode: r.Episode}
+}
+
+// what was restored
+type Report struct {
+	Watchlist int `json:"watchlist"`
+	Watched   int `json:"watched"`
+	Ratings   int `json:"ratings"`
+}
+
+// reads and writes the data of users. TMDB is only used to name the shows of
+// watched episodes that are neither on the watchlist nor rated and may be nil
+type Backup struct {
+	TMDB      *themoviedb.Client
+	Watchlist *watchlist.Store
+	Progress  *progress.Store
+	Ratings   *ratings.Store
+}
+
+func New(client *themoviedb.Client, wl *watchlist.Store, pr *progress.Store, rs *ratings.Store) *Backup {
+	return &Backup{TMDB: client, Watchlist: wl, Progress: pr, Ratings: rs}
+}
+
+// collects the data of the user
+func (b *Backup) Export(userID int, username string) (*Data, error) {
+	data := &Data{Version: Version, Username: username, Exported: time.Now().UTC(), Watched: []Watched{}, Ratings: []Rating{}}
+	names := map[int]string{}
+
+	var err error
+	if data.Watchlist, err = b.Watchlist.List(userID); err != nil {
+		return nil, err
+	}
+	for _, entry := range data.Watchlist {
+		names[entry.ShowID] = entry.Name
+	}
+
+	reviews, err := b.Ratings.ByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		t := review.Target
+		data.Ratings = append(data.Ratings, Rating{t.Kind, t.ShowID, t.Season, t.Episode, review.Name, review.Rating, review.Text, review.Updated})
+		if t.Kind == ratings.Show {
+			names[t.ShowID] = review.Name
+		}
+	}
+
+	marks, err := b.Progress.History(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, mark := range marks {
+		name, err := b.showName(names, mark.ShowID)
+		if err != nil {
+			return nil, err
+		}
+		data.Watched = append(data.Watched, Watched{mark.ShowID, name, mark.Episode.Season, mark.Episode.Number, mark.At})
+	}
+	return data, nil
+}
+
+func (b *Backup) showName(names map[int]string, id int) (string, error) {
+	if name, ok := names[id]; ok || b.TMDB == nil {
+		return name, nil
+	}
+	show, err := b.TMDB.GetTVShowDetails(strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	names[id] = show.Name
+	return show.Name, nil
+}
+
+// checks the whole export before anything is written
+func (data *Data) Validate() error {
+	if data.Version != Version {
+		return fmt.Errorf("unsupported export version %d", data.Version)
+	}
+	for _, entry := range data.Watchlist {
+		if entry.ShowID <= 0 {
+			return fmt.Errorf("watchlist: invalid show id %d", entry.ShowID)
+		}
+	}
+	for _, w := range data.Watched {
+		if w.ShowID <= 0 || w.Season < 0 || w.Episode <= 0 {
+			return fmt.Errorf("watched: invalid episode %d S%02dE%02d", w.ShowID, w.Season, w.Episode)
+		}
+	}
+	for _, r := range data.Ratings {
+		if !r.target().Valid() {
+			return fmt.Errorf("ratings: %w", ratings.ErrInvalidTarget)
+		}
+		if r.Rating < ratings.MinRating || r.Rating > ratings.MaxRating {
+			return fmt.Errorf("ratings: %s: rating must be between %d and %d", r.Name, ratings.MinRating, ratings.MaxRating)
+		}
+	}
+	return nil
+}
+
+// adds the exported data to the user. what the user has already is kept, so
+// restoring the same export twice changes nothing
+func (b *Backup) Restore(userID int, username string, data *Data) (*Report, error) {
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for _, entry := range data.Watchlist {
+		if err := b.Watchlist.Add(userID, entry); err != nil {
+			return nil, err
+		}
+		report.Watchlist++
+	}
+
+	marks := make([]progress.Mark, 0, len(data.Watched))
+	for _, w := range data.Watched {
+		marks = append(marks, progress.Mark{ShowID: w.ShowID, Episode: progress.Episode{Season: w.Season, Number: w.Episode}, At: w.WatchedAt})
+	}
+	if err := b.Progress.Restore(userID, marks); err != nil {
+		return nil, err
+	}
+	report.Watched = len(marks)
+
+	// the newer review wins if the user rated the same target here and in the export
+	for _, r := range data.Ratings {
+		current, err := b.Ratings.For(r.target())
+		if err != nil {
+			return nil, err
+		}
+		if newerThan(current, userID, r.Updated) {
+			continue
+		}
+		review := ratings.Review{Target: r.target(), UserID: userID, Username: username, Name: r.Name, Rating: r.Rating, Text: r.Text, Updated: r.Updated}
+		if err := b.Ratings.Restore(review); err != nil {
+			return nil, err
+		}
+		report.Ratings++
+	}
+	return report, nil
+}
+
+func newerThan(reviews []ratings.Review, userID int, updated time.Time) bool {
+	for _, review := range reviews {
+		if review.UserID == userID && review.Updated.After(updated) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+var exported = time.Date(2021, 3, 4, 20, 0, 0, 0, time.UTC)
+
+func GetTestBackup(t *testing.T) *Backup {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tv/1399" {
+			w.Write([]byte(`{"id":1399,"name":"Game of Thrones"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(mockServer.Close)
+	client := themoviedb.NewClient(&http.Client{Timeout: time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(mockServer.URL)
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wl, _ := watchlist.NewStore(db)
+	pr, _ := progress.NewStore(db)
+	rs, _ := ratings.NewStore(db)
+
+	return New(client, wl, pr, rs)
+}
+
+func GetTestData() *Data {
+	return &Data{
+		Version:   Version,
+		Username:  "jonas",
+		Exported:  exported,
+		Watchlist: []watchlist.Entry{{ShowID: 70523, Name: "Dark", PosterPath: "/dark.jpg", Added: exported}},
+		Watched: []Watched{
+			{ShowID: 1399, Name: "Game of Thrones", Season: 1, Episode: 1, WatchedAt: exported},
+			{ShowID: 70523, Name: "Dark", Season: 1, Episode: 1, WatchedAt: exported},
+		},
+		Ratings: []Rating{{Kind: ratings.Episode, ShowID: 70523, Season: 1, Episode: 3, Name: "Dark S01E03 Past and Present", Rating: 9, Text: "Everything is connected", Updated: exported}},
+	}
+}
+
+func TestExportAndRestore(t *testing.T) {
+
+	b := GetTestBackup(t)
+	data := GetTestData()
+
+	report, err := b.Restore(1, "jonas", data)
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Watchlist: 1, Watched: 2, Ratings: 1}, report)
+
+	_, err = b.Restore(1, "jonas", data)
+	assert.Nil(t, err, "restoring twice should not fail")
+
+	export, err := b.Export(1, "jonas")
+	assert.Nil(t, err)
+	export.Exported = exported
+	assert.Equal(t, data, export, "restoring twice should not change anything")
+}
+
+func TestRestoreKeepsNewerRatings(t *testing.T) {
+
+	b := GetTestBackup(t)
+	data := GetTestData()
+	rating := data.Ratings[0]
+	b.Ratings.Put(ratings.Review{Target: rating.target(), UserID: 1, Username: "jonas", Name: rating.Name, Rating: 4})
+
+	report, err := b.Restore(1, "jonas", data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.Ratings)
+	reviews, _ := b.Ratings.ByUser(1)
+	assert.Equal(t, 4, reviews[0].Rating)
+}
+
+func TestRestoreValidatesFirst(t *testing.T) {
+
+	b := GetTestBackup(t)
+	data := GetTestData()
+	data.Ratings[0].Rating = 11
+
+	_, err := b.Restore(1, "jonas", data)
+
+	assert.NotNil(t, err)
+	assert.False(t, b.Watchlist.Has(1, 70523), "nothing should be restored from an invalid export")
+
+	data = GetTestData()
+	data.Version = 2
+	_, err = b.Restore(1, "jonas", data)
+	assert.EqualError(t, err, "unsupported export version 2")
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/watchlist"
+)
+
+// the kinds of rows of the csv export
+const (
+	rowWatchlist = "watchlist"
+	rowWatched   = "watched"
+	rowRating    = "rating"
+)
+
+// the columns of the csv export. every row is one watchlist entry, watched episode or rating
+var csvHeader = []string{"type", "kind", "show_id", "season", "episode", "name", "rating", "text", "date"}
+
+func WriteJSON(w io.Writer, data *Data) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// writes the data as a single csv. version, user and export date are left out
+func WriteCSV(w io.Writer, data *Data) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, e := range data.Watchlist {
+		cw.Write(row(rowWatchlist, ratings.Show, e.ShowID, 0, 0, e.Name, 0, "", e.Added))
+	}
+	for _, e := range data.Watched {
+		cw.Write(row(rowWatched, ratings.Episode, e.ShowID, e.Season, e.Episode, e.Name, 0, "", e.WatchedAt))
+	}
+	for _, r := range data.Ratings {
+		cw.Write(row(rowRating, r.Kind, r.ShowID, r.Season, r.Episode, r.Name, r.Rating, r.Text, r.Updated))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func row(kind, level string, show, season, episode int, name string, rating int, text string, date time.Time) []string {
+	number := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	return []string{kind, level, strconv.Itoa(show), number(season), number(episode), name, number(rating), text, date.UTC().Format(time.RFC3339)}
+}
+
+// reads an export written by WriteJSON or WriteCSV
+func Read(r io.Reader) (*Data, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, errors.New("the export is empty")
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+			continue
+		case '{':
+			return ReadJSON(br)
+		}
+		return ReadCSV(br)
+	}
+}
+
+func ReadJSON(r io.Reader) (*Data, error) {
+	data := &Data{}
+	if err := json.NewDecoder(r).Decode(data); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+	return data, nil
+}
+
+func ReadCSV(r io.Reader) (*Data, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil || strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+		return nil, errors.New("invalid export: unknown csv columns")
+	}
+
+	data := &Data{Version: Version, Watchlist: []watchlist.Entry{}, Watched: []Watched{}, Ratings: []Rating{}}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+
+		var n [4]int
+		for i, column := range []int{2, 3, 4, 6} {
+			if record[column] == "" {
+				continue
+			}
+			if n[i], err = strconv.Atoi(record[column]); err != nil {
+				return nil, fmt.Errorf("invalid export: line %d: %s is not a number", line, csvHeader[column])
+			}
+		}
+		show, season, episode, rating := n[0], n[1], n[2], n[3]
+		date, err := time.Parse(time.RFC3339, record[8])
+		if err != nil {
+			return nil, fmt.Errorf("invalid export: line %d: invalid date", line)
+		}
+
+		switch record[0] {
+		case rowWatchlist:
+			data.Watchlist = append(data.Watchlist, watchlist.Entry{ShowID: show, Name: record[5], Added: date})
+		case rowWatched:
+			data.Watched = append(data.Watched, Watched{show, record[5], season, episode, date})
+		case rowRating:
+			data.Ratings = append(data.Ratings, Rating{record[1], show, season, episode, record[5], rating, record[7], date})
+		default:
+			return nil, fmt.Errorf("invalid export: line %d: unknown type %q", line, record[0])
+		}
+	}
+}
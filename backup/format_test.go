@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+
+	data := GetTestData()
+	buf := &bytes.Buffer{}
+
+	assert.Nil(t, WriteJSON(buf, data))
+	read, err := Read(strings.NewReader("\n  " + buf.String()))
+
+	assert.Nil(t, err)
+	assert.Equal(t, data, read)
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+
+	data := GetTestData()
+	buf := &bytes.Buffer{}
+
+	assert.Nil(t, WriteCSV(buf, data))
+	assert.Contains(t, buf.String(), "rating,episode,70523,1,3,Dark S01E03 Past and Present,9,Everything is connected,2021-03-04T20:00:00Z\n")
+	read, err := Read(buf)
+
+	assert.Nil(t, err)
+	// the csv has no room for the user and posters
+	data.Username, data.Exported, data.Watchlist[0].PosterPath = "", read.Exported, ""
+	assert.Equal(t, data, read)
+}
+
+func TestReadInvalidExports(t *testing.T) {
+
+	for name, export := range map[string]string{
+		"empty":       "",
+		"json":        `{"version":`,
+		"columns":     "title,year\nDark,2017\n",
+		"number":      "type,kind,show_id,season,episode,name,rating,text,date\nwatched,episode,dark,1,1,Dark,,,2021-03-04T20:00:00Z\n",
+		"date":        "type,kind,show_id,season,episode,name,rating,text,date\nwatched,episode,70523,1,1,Dark,,,yesterday\n",
+		"type":        "type,kind,show_id,season,episode,name,rating,text,date\nfavorite,show,70523,,,Dark,,,2021-03-04T20:00:00Z\n",
+		"field count": "type,kind,show_id,season,episode,name,rating,text,date\nwatched,episode,70523\n",
+	} {
+		_, err := Read(strings.NewReader(export))
+		assert.NotNil(t, err, name)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bereths.com/netstar/calendar"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+	"github.com/gorilla/mux"
+)
+
+// the upcoming episodes grouped by day and the urls to subscribe to them. only hashes of the
+// secret token are stored, so the urls are only known on the page showing them after they were created
+type CalendarPage struct {
+	Days    []CalendarDay
+	FeedURL string
+	AtomURL string
+	RSSURL  string
+}
+
+type CalendarDay struct {
+	Date   string
+	Events []calendar.Event
+}
+
+// lists the upcoming episodes of the shows on the watchlist
+func CalendarPageHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		events, err := upcomingEpisodes(themoviedbAPI, wl, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		token, err := feedToken(w, r, manager, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		page := &CalendarPage{}
+		if token != "" {
+			page.FeedURL = absoluteURL(r, "/calendar/"+token+".ics")
+			page.AtomURL = absoluteURL(r, "/feeds/watchlist/"+token+".atom")
+			page.RSSURL = absoluteURL(r, "/feeds/watchlist/"+token+".rss")
+		}
+		for _, e := range events {
+			if n := len(page.Days); n == 0 || page.Days[n-1].Date != e.Date {
+				page.Days = append(page.Days, CalendarDay{Date: e.Date})
+			}
+			day := &page.Days[len(page.Days)-1]
+			day.Events = append(day.Events, e)
+		}
+
+		render(w, r, calendarPage, page)
+	}
+}
+
+// the feed token created just now, by the first visit or the reset form. "" if it was created
+// earlier, only its hash is known then
+func feedToken(w http.ResponseWriter, r *http.Request, manager *users.Manager, userID int) (string, error) {
+	if token := manager.Flash(w, r); token != "" {
+		return token, nil
+	}
+	return manager.Store.FeedToken(userID)
+}
+
+// the pages showing the secret urls, the reset form goes back to one of them
+var feedPages = map[string]bool{"/calendar": true, "/watchlist": true}
+
+// creates new secret calendar and feed urls, the old ones stop working. the new urls are
+// handed to the page of the form once, so reloading it does not replace them again
+func ResetCalendarHandler(manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		token, err := manager.Store.ResetFeedToken(user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		next := r.PostFormValue("next")
+		if !feedPages[next] {
+			next = "/calendar"
+		}
+		manager.SetFlash(w, next, token)
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	}
+}
+
+// serves the upcoming episodes as iCalendar to whoever knows the secret token of the url
+func CalendarFeedHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := manager.Store.FeedUser(mux.Vars(r)["token"])
+		if err == users.ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		events, err := upcomingEpisodes(themoviedbAPI, wl, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		if err := calendar.WriteICS(w, "Netstar", events, time.Now()); err != nil {
+			log.Printf("Could not write calendar: %v", err)
+		}
+	}
+}
+
+func upcomingEpisodes(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, userID int) ([]calendar.Event, error) {
+	entries, err := wl.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ShowID
+	}
+	return calendar.Upcoming(themoviedbAPI, ids, time.Now().Format("2006-01-02")), nil
+}
+
+// the url of path on this server as the client reached it, for links used outside of netstar
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path)
+}
@@ -0,0 +1,95 @@
+// Package calendar collects the upcoming episodes of shows and exports them as iCalendar.
+package calendar
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// an episode airing on Date (formatted like 2006-01-02)
+type Event struct {
+	ShowID   int    `json:"show_id"`
+	ShowName string `json:"show_name"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+	Name     string `json:"name"`
+	Overview string `json:"overview"`
+	Date     string `json:"air_date"`
+}
+
+// the episodes of shows airing today or later. themoviedb only announces the next
+// episode of a show, so the rest of its season is looked up for the later ones.
+// shows that fail to load are logged and left out
+func Upcoming(client *themoviedb.Client, showIDs []int, today string) []Event {
+	var mu sync.Mutex
+	events := []Event{}
+
+	themoviedb.Parallel(len(showIDs), func(i int) {
+		found, err := upcoming(client, strconv.Itoa(showIDs[i]), today)
+		if err != nil {
+			log.Printf("Could not load upcoming episodes of %d: %v", showIDs[i], err)
+			return
+		}
+		mu.Lock()
+		events = append(events, found...)
+		mu.Unlock()
+	})
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.ShowName != b.ShowName {
+			return a.ShowName < b.ShowName
+		}
+		if a.Season != b.Season {
+			return a.Season < b.Season
+		}
+		return a.Episode < b.Episode
+	})
+	return events
+}
+
+func upcoming(client *themoviedb.Client, id, today string) ([]Event, error) {
+	show, err := client.GetTVShowDetails(id)
+	if err != nil {
+		return nil, err
+	}
+
+	next := show.NextEpisodeToAir
+	if next == nil {
+		return nil, nil
+	}
+
+	season, err := client.GetSeasonDetails(id, strconv.Itoa(next.SeasonNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, ep := range season.Episodes {
+		if ep.AirDate == "" || ep.AirDate < today || ep.EpisodeNumber < next.EpisodeNumber {
+			continue
+		}
+		events = append(events, Event{
+			ShowID:   show.ID,
+			ShowName: show.Name,
+			Season:   season.SeasonNumber,
+			Episode:  ep.EpisodeNumber,
+			Name:     ep.Name,
+			Overview: ep.Overview,
+			Date:     ep.AirDate,
+		})
+	}
+
+	// the season list may not know the announced episode yet
+	if len(events) == 0 && next.AirDate >= today {
+		events = append(events, Event{show.ID, show.Name, next.SeasonNumber, next.EpisodeNumber, next.Name, next.Overview, next.AirDate})
+	}
+	return events, nil
+}
@@ -0,0 +1,58 @@
+package calendar
+
+import (
+	"net/http"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockClient(t *testing.T) *themoviedb.Client {
+	return themoviedbtest.NewClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/1":
+			w.Write([]byte(`{"id":1,"name":"Running","next_episode_to_air":{"air_date":"2022-05-08","season_number":2,"episode_number":2,"name":"Two"}}`))
+		case "/tv/1/season/2":
+			w.Write([]byte(`{"season_number":2,"episodes":[
+				{"episode_number":1,"name":"One","air_date":"2022-05-01"},
+				{"episode_number":2,"name":"Two","air_date":"2022-05-08"},
+				{"episode_number":3,"name":"Three","air_date":"2022-05-15"},
+				{"episode_number":4,"name":"Four","air_date":""}]}`))
+		case "/tv/2":
+			w.Write([]byte(`{"id":2,"name":"Another","next_episode_to_air":{"air_date":"2022-05-08","season_number":1,"episode_number":1,"name":"Pilot"}}`))
+		case "/tv/2/season/1":
+			w.Write([]byte(`{"season_number":1,"episodes":[]}`))
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","next_episode_to_air":null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestUpcoming(t *testing.T) {
+
+	events := Upcoming(GetMockClient(t), []int{1, 2, 70523, 404}, "2022-05-05")
+
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, Event{ShowID: 2, ShowName: "Another", Season: 1, Episode: 1, Name: "Pilot", Date: "2022-05-08"}, events[0], "announced episode should be used if the season does not list it")
+		assert.Equal(t, "Two", events[1].Name)
+		assert.Equal(t, "Three", events[2].Name)
+	}
+}
+
+func TestUpcomingBoundsConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	client := themoviedbtest.NewClient(t, concurrency.Slow(http.NotFound))
+
+	ids := make([]int, 30)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	assert.Empty(t, Upcoming(client, ids, "2022-01-01"))
+
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
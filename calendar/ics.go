@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// lines of an iCalendar file must not be longer than 75 octets
+const maxLineLength = 75
+
+// writes events as an iCalendar (RFC 5545) feed with one all day event per episode.
+// stamp is the time the feed was created
+func WriteICS(w io.Writer, name string, events []Event, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		bw.WriteString(fold(fmt.Sprintf(format, args...)))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//netstar//calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", escape(name))
+
+	for _, e := range events {
+		start, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			continue
+		}
+
+		line("BEGIN:VEVENT")
+		// stable per episode, so calendar apps update events instead of duplicating them
+		line("UID:netstar-%d-s%de%d@netstar", e.ShowID, e.Season, e.Episode)
+		line("DTSTAMP:%s", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:%s", start.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", start.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%s", escape(Summary(e)))
+		if e.Overview != "" {
+			line("DESCRIPTION:%s", escape(e.Overview))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// the title of an episode like "Dark S03E01 Deja-vu"
+func Summary(e Event) string {
+	summary := fmt.Sprintf("%s S%02dE%02d", e.ShowName, e.Season, e.Episode)
+	if e.Name != "" {
+		summary += " " + e.Name
+	}
+	return summary
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// splits long lines into continuation lines starting with a space, without cutting utf-8 characters
+func fold(s string) string {
+	if len(s) <= maxLineLength {
+		return s
+	}
+
+	var b strings.Builder
+	limit := maxLineLength
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			// the leading space counts to the line length
+			n, limit = 0, maxLineLength-1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteICS(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	events := []Event{{ShowID: 70523, ShowName: "Dark", Season: 3, Episode: 1, Name: "Deja-vu", Overview: "Adam, Martha; and\nJonas", Date: "2020-06-27"}}
+
+	err := WriteICS(buf, "Netstar", events, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	ics := buf.String()
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "UID:netstar-70523-s3e1@netstar\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20200601T120000Z\r\n")
+	assert.Contains(t, ics, "DTSTART;VALUE=DATE:20200627\r\nDTEND;VALUE=DATE:20200628\r\n")
+	assert.Contains(t, ics, "SUMMARY:Dark S03E01 Deja-vu\r\n")
+	assert.Contains(t, ics, `DESCRIPTION:Adam\, Martha\; and\nJonas`)
+}
+
+func TestFold(t *testing.T) {
+
+	assert.Equal(t, "short", fold("short"))
+
+	long := "DESCRIPTION:" + strings.Repeat("ä", 100)
+	lines := strings.Split(fold(long), "\r\n")
+
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, long, strings.ReplaceAll(fold(long), "\r\n ", ""), "unfolding should give the line back")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+)
+
+// a themoviedb with a show whose next episode airs far in the future
+func GetMockCalendarServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/1":
+			w.Write([]byte(`{"id":1,"name":"Running","next_episode_to_air":{"air_date":"2999-01-01","season_number":1,"episode_number":1,"name":"Pilot"}}`))
+		case "/tv/1/season/1":
+			w.Write([]byte(`{"season_number":1,"episodes":[{"episode_number":1,"name":"Pilot","air_date":"2999-01-01"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+var feedURL = regexp.MustCompile(`value="(http://[^"]+\.ics)"`)
+
+func TestCalendarAndFeed(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockCalendarServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	all, _ := app.Users.Store.All()
+	app.Watchlist.Add(all[0].ID, watchlist.Entry{ShowID: 1, Name: "Running"})
+
+	resp, _ := client.Get(mockServer.URL + "/calendar")
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, "2999-01-01")
+	assert.Contains(t, body, "Pilot")
+
+	m := feedURL.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatal("no feed url on calendar page")
+	}
+
+	// calendar apps have no session
+	resp, _ = http.Get(m[1])
+	assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, ReadBody(t, resp), "SUMMARY:Running S01E01 Pilot")
+
+	// only the hash of the token is kept
+	token := GetCSRFToken(t, client, mockServer.URL+"/calendar")
+	resp, _ = client.Get(mockServer.URL + "/calendar")
+	assert.NotContains(t, ReadBody(t, resp), m[1])
+
+	resp, _ = client.PostForm(mockServer.URL+"/calendar/reset", url.Values{"csrf_token": {token}})
+	assert.Equal(t, "GET", resp.Request.Method, "the form should redirect to the calendar")
+	renewed := feedURL.FindStringSubmatch(ReadBody(t, resp))
+	if renewed == nil {
+		t.Fatal("no new feed url after reset")
+	}
+	assert.NotEqual(t, m[1], renewed[1])
+
+	// reloading shows the calendar without replacing the url again
+	resp, _ = client.Get(mockServer.URL + "/calendar")
+	assert.NotContains(t, ReadBody(t, resp), renewed[1])
+
+	resp, _ = http.Get(m[1])
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "old url should stop working")
+
+	resp, _ = http.Get(renewed[1])
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// the age rating of the show in the region of the visitor
+type CertificationSection struct {
+	Region string
+	// nil if the show was not rated in Region
+	*themoviedb.ContentRating
+}
+
+// the certification and keywords of the show
+func loadCertification(app *App, r *http.Request, id int) *CertificationSection {
+	ratings, err := app.TMDB.GetContentRatings(strconv.Itoa(id))
+	if !fetched(err, "content ratings of %d", id) {
+		return nil
+	}
+	region := requestRegion(app, r)
+	return &CertificationSection{Region: region, ContentRating: ratings.For(region)}
+}
+
+func loadKeywords(themoviedbAPI *themoviedb.Client, id int) []themoviedb.Keyword {
+	keywords, err := themoviedbAPI.GetKeywords(strconv.Itoa(id))
+	if !fetched(err, "keywords of %d", id) {
+		return nil
+	}
+	return keywords.Results
+}
+
+// hides shows certified for an older audience than Max in Region, like 12 in DE. shows that
+// were not rated in Region are hidden too unless KeepUnrated is set. the certifications are
+// remembered, they hardly change
+type CertificationFilter struct {
+	TMDB   *themoviedb.Client
+	Region string
+	Max    string
+	// lets shows without a certification in Region through
+	KeepUnrated bool
+
+	mu sync.Mutex
+	// the order of the certifications of Region, loaded on first use
+	order map[string]int
+	// the certification of the shows seen so far, empty if not rated
+	ratings map[int]string
+}
+
+// the most shows whose certifications are remembered
+const maxRememberedCertifications = 10000
+
+func NewCertificationFilter(themoviedbAPI *themoviedb.Client, region, max string) *CertificationFilter {
+	return &CertificationFilter{TMDB: themoviedbAPI, Region: region, Max: max, ratings: map[int]string{}}
+}
+
+// the shows the audience may see. a nil filter lets every show through. the filter fails closed:
+// without the certifications of Region no show is let through, neither is a show whose
+// certification could not be looked up
+func (f *CertificationFilter) Filter(shows []themoviedb.TVShow) []themoviedb.TVShow {
+	if f == nil || len(shows) == 0 {
+		return shows
+	}
+	order, max, err := f.limit()
+	if err != nil {
+		log.Printf("Hiding all shows: %v", err)
+		return nil
+	}
+
+	ratings := f.showRatings(shows)
+	filtered := make([]themoviedb.TVShow, 0, len(shows))
+	for _, show := range shows {
+		rating, known := ratings[show.ID]
+		if !known {
+			continue
+		}
+		if rank, rated := order[rating]; rated && rank <= max || !rated && f.KeepUnrated {
+			filtered = append(filtered, show)
+		}
+	}
+	return filtered
+}
+
+// loads the certifications of Region and makes sure Max is one of them, so a misconfigured
+// filter is noticed when netstar starts
+func (f *CertificationFilter) Check() error {
+	_, _, err := f.limit()
+	return err
+}
+
+// the order of the certifications of Region and the rank of Max in it
+func (f *CertificationFilter) limit() (map[string]int, int, error) {
+	order, err := f.certifications()
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not load certifications: %w", err)
+	}
+	max, ok := order[f.Max]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown certification %q in %s", f.Max, f.Region)
+	}
+	return order, max, nil
+}
+
+func (f *CertificationFilter) certifications() (map[string]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.order != nil {
+		return f.order, nil
+	}
+
+	certifications, err := f.TMDB.GetTVCertifications()
+	if err != nil {
+		return nil, err
+	}
+	f.order = map[string]int{}
+	for _, certification := range certifications.Of(f.Region) {
+		f.order[certification.Certification] = certification.Order
+	}
+	return f.order, nil
+}
+
+// the certifications of shows in Region, looked up concurrently if not remembered.
+// shows whose lookup failed are missing
+func (f *CertificationFilter) showRatings(shows []themoviedb.TVShow) map[int]string {
+	ratings := map[int]string{}
+	var missing []int
+	f.mu.Lock()
+	for _, show := range shows {
+		if rating, ok := f.ratings[show.ID]; ok {
+			ratings[show.ID] = rating
+		} else {
+			missing = append(missing, show.ID)
+		}
+	}
+	f.mu.Unlock()
+
+	var mu sync.Mutex
+	themoviedb.Parallel(len(missing), func(i int) {
+		id := missing[i]
+		contentRatings, err := f.TMDB.GetContentRatings(strconv.Itoa(id))
+		if err != nil {
+			log.Printf("Could not load content ratings of %d: %v", id, err)
+			return
+		}
+		rating := ""
+		if r := contentRatings.For(f.Region); r != nil {
+			rating = r.Rating
+		}
+		mu.Lock()
+		ratings[id] = rating
+		mu.Unlock()
+	})
+
+	f.mu.Lock()
+	if len(f.ratings) > maxRememberedCertifications {
+		f.ratings = map[int]string{}
+	}
+	for _, id := range missing {
+		if rating, ok := ratings[id]; ok {
+			f.ratings[id] = rating
+		}
+	}
+	f.mu.Unlock()
+	return ratings
+}
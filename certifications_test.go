@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockCertificationServer(t *testing.T, requests *int32) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+		case "/tv/70523/keywords":
+			w.Write([]byte(`{"id":70523,"results":[{"id":4379,"name":"time travel"}]}`))
+		case "/tv/70523/content_ratings":
+			w.Write([]byte(`{"id":70523,"results":[{"iso_3166_1":"US","rating":"TV-MA","descriptors":["violence"]},{"iso_3166_1":"DE","rating":"16"}]}`))
+		case "/tv/1/content_ratings":
+			w.Write([]byte(`{"id":1,"results":[{"iso_3166_1":"DE","rating":"6"}]}`))
+		case "/tv/3/content_ratings":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/tv/2/content_ratings":
+			w.Write([]byte(`{"id":2,"results":[]}`))
+		case "/certification/tv/list":
+			w.Write([]byte(`{"certifications":{"DE":[{"certification":"0","order":1},{"certification":"6","order":2},{"certification":"12","order":3},{"certification":"16","order":4},{"certification":"18","order":5}]}}`))
+		case "/search/tv":
+			w.Write([]byte(`{"page":1,"results":[{"id":70523,"name":"Dark"},{"id":1,"name":"Die Sendung mit der Maus"},{"id":2,"name":"Unrated"}],"total_pages":1,"total_results":3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestDetailsShowCertificationAndKeywords(t *testing.T) {
+
+	var requests int32
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockCertificationServer(t, &requests).URL)
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI, Region: "DE"}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, `title="Certification in DE">16</span>`)
+	assert.Contains(t, body, "time travel")
+
+	resp, _ = http.Get(mockServer.URL + "/details?id=70523&region=US")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, ">TV-MA</span>")
+	assert.Contains(t, body, "violence")
+
+	resp, _ = http.Get(mockServer.URL + "/details?id=70523&region=FR")
+	assert.Contains(t, ReadBody(t, resp), "Not rated in FR")
+}
+
+func TestSearchHidesShowsAboveMaxCertification(t *testing.T) {
+
+	var requests int32
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockCertificationServer(t, &requests).URL)
+	filter := NewCertificationFilter(themoviedbAPI, "DE", "12")
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI, Region: "DE", Certifications: filter}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/search?q=d")
+	body := ReadBody(t, resp)
+
+	assert.NotContains(t, body, "Dark")
+	assert.Contains(t, body, "Die Sendung mit der Maus")
+	assert.NotContains(t, body, "Unrated", "shows without a certification should be hidden")
+	assert.Contains(t, body, "2 shows on this page are hidden")
+
+	before := atomic.LoadInt32(&requests)
+	resp, _ = http.Get(mockServer.URL + "/search?q=d")
+	resp.Body.Close()
+	assert.Equal(t, before+1, atomic.LoadInt32(&requests), "certifications should be remembered")
+}
+
+func TestCertificationFilter(t *testing.T) {
+
+	var requests int32
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockCertificationServer(t, &requests).URL)
+	shows := []themoviedb.TVShow{{ID: 70523}, {ID: 1}}
+
+	var filter *CertificationFilter
+	assert.Len(t, filter.Filter(shows), 2, "a nil filter should keep every show")
+
+	assert.Len(t, NewCertificationFilter(themoviedbAPI, "DE", "18").Filter(shows), 2)
+	assert.Len(t, NewCertificationFilter(themoviedbAPI, "DE", "0").Filter(shows), 0)
+	assert.Len(t, NewCertificationFilter(themoviedbAPI, "DE", "PG").Filter(shows), 0, "unknown certifications should hide everything")
+
+	assert.Nil(t, NewCertificationFilter(themoviedbAPI, "DE", "12").Check())
+	assert.NotNil(t, NewCertificationFilter(themoviedbAPI, "DE", "PG").Check())
+	assert.NotNil(t, NewCertificationFilter(themoviedbAPI, "FR", "12").Check())
+}
+
+func TestCertificationFilterFailsClosed(t *testing.T) {
+
+	var requests int32
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockCertificationServer(t, &requests).URL)
+
+	filter := NewCertificationFilter(themoviedbAPI, "DE", "18")
+	filtered := filter.Filter([]themoviedb.TVShow{{ID: 1}, {ID: 2}, {ID: 3}})
+	if assert.Len(t, filtered, 1, "unrated shows and shows whose content ratings could not be loaded should be hidden") {
+		assert.Equal(t, 1, filtered[0].ID)
+	}
+
+	filter.KeepUnrated = true
+	filtered = filter.Filter([]themoviedb.TVShow{{ID: 1}, {ID: 2}, {ID: 3}})
+	if assert.Len(t, filtered, 2, "unrated shows should be kept if asked for") {
+		assert.Equal(t, 1, filtered[0].ID)
+		assert.Equal(t, 2, filtered[1].ID)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	themoviedbAPI.SetBaseURL(failing.URL)
+	filter = NewCertificationFilter(themoviedbAPI, "DE", "18")
+	assert.Empty(t, filter.Filter([]themoviedb.TVShow{{ID: 1}}), "without certifications no show should be shown")
+	assert.NotNil(t, filter.Check())
+}
+
+func TestCertificationFilterBoundsConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	themoviedbAPI := themoviedbtest.NewClient(t, concurrency.Slow(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/certification/tv/list" {
+			w.Write([]byte(`{"certifications":{"DE":[{"certification":"0","order":1},{"certification":"12","order":2}]}}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"iso_3166_1":"DE","rating":"0"}]}`))
+	}))
+	shows := make([]themoviedb.TVShow, 30)
+	for i := range shows {
+		shows[i].ID = i + 1
+	}
+
+	assert.Len(t, NewCertificationFilter(themoviedbAPI, "DE", "12").Filter(shows), 30)
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
@@ -0,0 +1,725 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bereths.com/netstar/backup"
+	"bereths.com/netstar/library"
+	"bereths.com/netstar/nfo"
+	"bereths.com/netstar/organize"
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/resolve"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/tui"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v2"
+)
+
+// exit codes of the cli commands so scripts can tell the failures apart
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
+
+// the environment a cli command runs in
+type CLI struct {
+	Config Config
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type command struct {
+	usage   string
+	summary string
+	run     func(cli *CLI, args []string) int
+}
+
+// all commands netstar knows besides starting the server
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"search":   {"search <query>", "search tv shows by name", searchCommand},
+		"show":     {"show <id>", "show the details of a tv show", showCommand},
+		"season":   {"season <id> <season>", "list the episodes of a season", seasonCommand},
+		"episode":  {"episode <id> <season> <episode>", "show the details of an episode", episodeCommand},
+		"export":   {"export <username>", "write the watchlist, watched episodes and ratings of a user (server stopped)", exportCommand},
+		"import":   {"import <username> [file]", "restore an export for a user (default stdin, server stopped)", importCommand},
+		"nfo":      {"nfo [dir...]", "write Kodi/Jellyfin nfo files and artwork for the matched episodes", nfoCommand},
+		"organize": {"organize <dir>", "rename and move episode files into a folder layout", organizeCommand},
+		"resolve":  {"resolve [file]", "look up a list of titles from a csv or text file (default stdin)", resolveCommand},
+		"scan":     {"scan [dir...]", "match the episode files of the library (default LIBRARY_DIRS)", scanCommand},
+		"tui":      {"tui", "browse tv shows in a full screen terminal ui", tuiCommand},
+		"help":     {"help", "print this help", helpCommand},
+	}
+}
+
+// runs the command given in args[0] and returns the exit code
+func RunCLI(args []string, stdout, stderr io.Writer) int {
+	cli := &CLI{Config: LoadCLIConfig("."), Stdout: stdout, Stderr: stderr}
+
+	if len(args) == 0 {
+		return helpCommand(cli, nil)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		helpCommand(&CLI{Stdout: stderr}, nil)
+		return exitUsage
+	}
+
+	return cmd.run(cli, args[1:])
+}
+
+// loads the config like the server does but falls back to plain environment
+// variables, so the cli can be used without an .env file
+func LoadCLIConfig(path string) Config {
+	config, err := LoadConfig(path)
+	if err != nil {
+		viper.Unmarshal(&config)
+	}
+	return config
+}
+
+// flags every tmdb command understands
+type outputFlags struct {
+	output string
+	lang   string
+	adult  bool
+}
+
+func newFlagSet(cli *CLI, name string) (*flag.FlagSet, *outputFlags) {
+	fs, of := newClientFlagSet(cli, name)
+	fs.StringVar(&of.output, "output", "table", "output format: table, json or yaml")
+	return fs, of
+}
+
+// creates a flag set with only the flags needed to create a themoviedb client
+func newClientFlagSet(cli *CLI, name string) (*flag.FlagSet, *outputFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(cli.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(cli.Stderr, "usage: netstar %s [flags]\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+
+	of := &outputFlags{}
+	fs.StringVar(&of.lang, "lang", cli.Config.Language, "language of the results, e.g. de-DE")
+	fs.BoolVar(&of.adult, "adult", cli.Config.IncludeAdult, "include adult content")
+	return fs, of
+}
+
+// parses flags and positional arguments in any order, so
+// "netstar search Dark --output json" works as well
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parses args and makes sure exactly n positional arguments are given.
+// if ok is false the command should stop and return code
+func parseCommandArgs(cli *CLI, fs *flag.FlagSet, of *outputFlags, args []string, n int) (positional []string, code int, ok bool) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, exitOK, false
+		}
+		return nil, exitUsage, false
+	}
+
+	switch of.output {
+	case "", "table", "json", "yaml":
+	default:
+		fmt.Fprintf(cli.Stderr, "unknown output format %q\n", of.output)
+		return nil, exitUsage, false
+	}
+
+	if len(positional) != n {
+		fs.Usage()
+		return nil, exitUsage, false
+	}
+	return positional, exitOK, true
+}
+
+// creates a themoviedb client for the given language and adult setting
+func (cli *CLI) client(of *outputFlags) *themoviedb.Client {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	client := themoviedb.NewClient(httpClient, cli.Config.API_KEY, of.lang, of.adult)
+	if cli.Config.APIURL != "" {
+		client.SetBaseURL(cli.Config.APIURL)
+	}
+	client.SetFallbackLanguages(cli.Config.fallbackLanguages()...)
+	return client
+}
+
+// prints the error and returns the matching exit code
+func (cli *CLI) fail(err error) int {
+	if themoviedb.IsNotFound(err) {
+		fmt.Fprintln(cli.Stderr, "not found:", err)
+		return exitNotFound
+	}
+	fmt.Fprintln(cli.Stderr, "error:", err)
+	return exitError
+}
+
+// writes v in the requested format. table is used to render the table output
+func (cli *CLI) print(of *outputFlags, v interface{}, table func(w io.Writer)) int {
+	var err error
+	switch of.output {
+	case "json":
+		enc := json.NewEncoder(cli.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(v)
+	case "yaml":
+		err = writeYAML(cli.Stdout, v)
+	default:
+		tw := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+		table(tw)
+		err = tw.Flush()
+	}
+
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// writes v as yaml using the same keys as the json output
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func searchCommand(cli *CLI, args []string) int {
+	fs, of := newFlagSet(cli, "search")
+	page := fs.Int("page", 1, "page of the results")
+	positional, code, ok := parseCommandArgs(cli, fs, of, args, 1)
+	if !ok {
+		return code
+	}
+
+	results, err := cli.client(of).SearchTVShows(positional[0], strconv.Itoa(*page))
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	if len(results.Results) == 0 {
+		fmt.Fprintf(cli.Stderr, "no tv shows found for %q\n", positional[0])
+		return exitNotFound
+	}
+
+	return cli.print(of, results, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tFIRST AIR DATE\tVOTE")
+		for _, show := range results.Results {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%.1f\n", show.ID, show.Name, show.FirstAirDate, show.VoteAverage)
+		}
+		fmt.Fprintf(w, "\npage %d of %d (%d results)\n", results.Page, results.TotalPages, results.TotalResults)
+	})
+}
+
+func showCommand(cli *CLI, args []string) int {
+	fs, of := newFlagSet(cli, "show")
+	positional, code, ok := parseCommandArgs(cli, fs, of, args, 1)
+	if !ok {
+		return code
+	}
+
+	show, err := cli.client(of).GetTVShowDetails(positional[0])
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	return cli.print(of, show, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\t%d\n", show.ID)
+		fmt.Fprintf(w, "NAME\t%s\n", show.Name)
+		fmt.Fprintf(w, "FIRST AIR DATE\t%s\n", show.FirstAirDate)
+		fmt.Fprintf(w, "STATUS\t%s\n", show.Status)
+		fmt.Fprintf(w, "SEASONS\t%d\n", show.NumberOfSeasons)
+		fmt.Fprintf(w, "EPISODES\t%d\n", show.NumberOfEpisodes)
+		fmt.Fprintf(w, "VOTE\t%.1f (%d)\n", show.VoteAverage, show.VoteCount)
+		fmt.Fprintf(w, "OVERVIEW\t%s\n", show.Overview)
+		fmt.Fprintln(w, "\nSEASON\tNAME\tEPISODES\tAIR DATE")
+		for _, season := range show.Seasons {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", season.SeasonNumber, season.Name, season.EpisodeCount, season.AirDate)
+		}
+	})
+}
+
+func seasonCommand(cli *CLI, args []string) int {
+	fs, of := newFlagSet(cli, "season")
+	positional, code, ok := parseCommandArgs(cli, fs, of, args, 2)
+	if !ok {
+		return code
+	}
+
+	season, err := cli.client(of).GetSeasonDetails(positional[0], positional[1])
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	return cli.print(of, season, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n\n", season.Name)
+		fmt.Fprintln(w, "EPISODE\tNAME\tAIR DATE\tVOTE")
+		for _, episode := range season.Episodes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%.1f\n", episode.EpisodeNumber, episode.Name, episode.AirDate, episode.VoteAverage)
+		}
+	})
+}
+
+func episodeCommand(cli *CLI, args []string) int {
+	fs, of := newFlagSet(cli, "episode")
+	positional, code, ok := parseCommandArgs(cli, fs, of, args, 3)
+	if !ok {
+		return code
+	}
+
+	episode, err := cli.client(of).GetEpisodeDetails(positional[0], positional[1], positional[2])
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	return cli.print(of, episode, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\t%d\n", episode.ID)
+		fmt.Fprintf(w, "NAME\t%s\n", episode.Name)
+		fmt.Fprintf(w, "EPISODE\tS%02dE%02d\n", episode.SeasonNumber, episode.EpisodeNumber)
+		fmt.Fprintf(w, "AIR DATE\t%s\n", episode.AirDate)
+		fmt.Fprintf(w, "VOTE\t%.1f (%d)\n", episode.VoteAverage, episode.VoteCount)
+		fmt.Fprintf(w, "OVERVIEW\t%s\n", episode.Overview)
+	})
+}
+
+func resolveCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "resolve")
+	format := fs.String("format", "", "input format: csv or lines (default by file extension)")
+	output := fs.String("output", "csv", "report format: csv or json")
+	concurrency := fs.Int("concurrency", 4, "number of parallel searches")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil || len(positional) > 1 || (*output != "csv" && *output != "json") {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fs.Usage()
+		return exitUsage
+	}
+
+	input := io.Reader(os.Stdin)
+	if len(positional) == 1 && positional[0] != "-" {
+		f, err := os.Open(positional[0])
+		if err != nil {
+			fmt.Fprintln(cli.Stderr, "error:", err)
+			return exitError
+		}
+		defer f.Close()
+		input = f
+
+		if *format == "" && strings.EqualFold(filepath.Ext(positional[0]), ".csv") {
+			*format = "csv"
+		}
+	}
+
+	queries, err := resolve.ReadQueries(input, *format)
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitUsage
+	}
+
+	matches := resolve.NewResolver(cli.client(of), *concurrency).Resolve(queries)
+
+	if *output == "json" {
+		err = resolve.WriteJSON(cli.Stdout, matches)
+	} else {
+		err = resolve.WriteCSV(cli.Stdout, matches)
+	}
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+
+	ambiguous := 0
+	for _, m := range matches {
+		if m.Ambiguous {
+			ambiguous++
+		}
+	}
+	fmt.Fprintf(cli.Stderr, "resolved %d titles, %d need review, %d failed\n", len(matches), ambiguous, resolve.Failed(matches))
+
+	if resolve.Failed(matches) > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+func scanCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "scan")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	dirs := positional
+	if len(dirs) == 0 {
+		dirs = cli.Config.libraryDirs()
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintln(cli.Stderr, "no directories given and LIBRARY_DIRS is not set")
+		return exitUsage
+	}
+
+	lib, err := library.OpenStore(cli.Config.libraryIndex())
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+
+	report, err := library.NewScanner(cli.client(of), lib).Scan(dirs)
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+
+	tw := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range report.Matched {
+		for _, e := range f.Episodes {
+			fmt.Fprintf(tw, "matched\t%s S%02dE%02d\t%s\n", f.ShowName, f.Season, e.Number, f.Path)
+		}
+	}
+	for _, u := range report.Unmatched {
+		fmt.Fprintf(tw, "unmatched\t%s\t%s\n", u.Reason, u.Path)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(cli.Stdout, "\n%d matched, %d unchanged, %d removed, %d unmatched\n",
+		len(report.Matched), report.Unchanged, report.Removed, len(report.Unmatched))
+	return exitOK
+}
+
+func nfoCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "nfo")
+	dryRun := fs.Bool("dry-run", false, "only print what would be written")
+	dirs, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	lib, err := library.OpenStore(cli.Config.libraryIndex())
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+
+	files := lib.Files()
+	if len(dirs) > 0 {
+		files = nil
+		for _, dir := range dirs {
+			dir, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Fprintln(cli.Stderr, "error:", err)
+				return exitError
+			}
+			files = append(files, lib.FilesIn(dir)...)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(cli.Stderr, "no matched episodes in the library, run netstar scan first")
+		return exitNotFound
+	}
+
+	exporter := nfo.NewExporter(cli.client(of), &http.Client{Timeout: 30 * time.Second})
+	exporter.DryRun = *dryRun
+	actions, err := exporter.Export(files)
+
+	tw := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	for _, a := range actions {
+		verb := "skip"
+		if a.Write && *dryRun {
+			verb = "would write"
+		} else if a.Write {
+			verb = "write"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", verb, a.Reason, a.Path)
+	}
+	tw.Flush()
+
+	if err != nil {
+		return cli.fail(err)
+	}
+	return exitOK
+}
+
+func organizeCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "organize")
+	target := fs.String("target", "", "directory to move the files to (default the given directory)")
+	templateText := fs.String("template", cli.Config.OrganizeTemplate, "naming template (default \""+organize.DefaultTemplate+"\")")
+	dryRun := fs.Bool("dry-run", false, "only print what would be moved")
+	undo := fs.Bool("undo", false, "undo the last run in the target directory")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil || len(positional) != 1 {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fs.Usage()
+		return exitUsage
+	}
+
+	if *target == "" {
+		*target = positional[0]
+	}
+	if *templateText == "" {
+		*templateText = organize.DefaultTemplate
+	}
+	journal := filepath.Join(*target, organize.JournalName)
+
+	lib, err := library.OpenStore(cli.Config.libraryIndex())
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+
+	if *undo {
+		undone, err := organize.Undo(journal, lib)
+		for _, m := range undone {
+			fmt.Fprintf(cli.Stdout, "restored\t%s\n", m.From)
+		}
+		if err != nil {
+			fmt.Fprintln(cli.Stderr, "error:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	template, err := organize.ParseTemplate(*templateText)
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitUsage
+	}
+
+	plan, err := organize.New(library.NewScanner(cli.client(of), lib), template, *target).Plan(positional[0])
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	tw := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range plan.Moves {
+		if m.Conflict != "" {
+			fmt.Fprintf(tw, "conflict\t%s\t%s\n", m.From, m.Conflict)
+		} else {
+			fmt.Fprintf(tw, "move\t%s\t-> %s\n", m.From, m.To)
+		}
+	}
+	for _, u := range plan.Unmatched {
+		fmt.Fprintf(tw, "unmatched\t%s\t%s\n", u.Path, u.Reason)
+	}
+	tw.Flush()
+
+	if *dryRun {
+		fmt.Fprintf(cli.Stdout, "\n%d to move, %d conflicts, %d unmatched, %d already organized\n",
+			len(plan.Ready()), len(plan.Moves)-len(plan.Ready()), len(plan.Unmatched), plan.Organized)
+		return exitOK
+	}
+
+	done, err := organize.Apply(plan, journal, lib)
+	fmt.Fprintf(cli.Stdout, "\nmoved %d files, undo with: netstar organize --undo --target %s %s\n", len(done), *target, positional[0])
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func exportCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "export")
+	fs.StringVar(&of.output, "output", "json", "output format: json or csv")
+	positional, err := parseArgs(fs, args)
+	if err != nil || len(positional) != 1 {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fs.Usage()
+		return exitUsage
+	}
+	if of.output != "json" && of.output != "csv" {
+		fmt.Fprintf(cli.Stderr, "unknown output format %q\n", of.output)
+		return exitUsage
+	}
+
+	b, user, done, err := cli.backup(of, positional[0])
+	if err != nil {
+		return cli.fail(err)
+	}
+	defer done()
+
+	data, err := b.Export(user.ID, user.Username)
+	if err != nil {
+		return cli.fail(err)
+	}
+	if of.output == "csv" {
+		err = backup.WriteCSV(cli.Stdout, data)
+	} else {
+		err = backup.WriteJSON(cli.Stdout, data)
+	}
+	if err != nil {
+		return cli.fail(err)
+	}
+	return exitOK
+}
+
+func importCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "import")
+	positional, err := parseArgs(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fs.Usage()
+		return exitUsage
+	}
+
+	var in io.Reader = os.Stdin
+	if len(positional) == 2 {
+		f, err := os.Open(positional[1])
+		if err != nil {
+			return cli.fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	data, err := backup.Read(in)
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	b, user, done, err := cli.backup(of, positional[0])
+	if err != nil {
+		return cli.fail(err)
+	}
+	defer done()
+
+	report, err := b.Restore(user.ID, user.Username, data)
+	if err != nil {
+		return cli.fail(err)
+	}
+	fmt.Fprintf(cli.Stdout, "restored %d watchlist entries, %d watched episodes and %d ratings for %s\n",
+		report.Watchlist, report.Watched, report.Ratings, user.Username)
+	return exitOK
+}
+
+// opens the database of the server for the data of username. done closes it again.
+// bolt locks the file while the server runs, so this gives up after a second instead of waiting
+func (cli *CLI) backup(of *outputFlags, username string) (b *backup.Backup, user *users.User, done func(), err error) {
+	db, err := bolt.Open(cli.Config.database(), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not open %s, stop the server first: %w", cli.Config.database(), err)
+	}
+	defer func() {
+		if err != nil {
+			db.Close()
+		}
+	}()
+
+	userStore, err := users.NewStore(db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if user, err = userStore.ByName(username); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", username, err)
+	}
+	wl, err := watchlist.NewStore(db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pr, err := progress.NewStore(db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rs, err := ratings.NewStore(db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return backup.New(cli.client(of), wl, pr, rs), user, func() { db.Close() }, nil
+}
+
+func tuiCommand(cli *CLI, args []string) int {
+	fs, of := newClientFlagSet(cli, "tui")
+	_, code, ok := parseCommandArgs(cli, fs, of, args, 0)
+	if !ok {
+		return code
+	}
+
+	if err := tui.New(cli.client(of)).Run(); err != nil {
+		fmt.Fprintln(cli.Stderr, "error:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func helpCommand(cli *CLI, args []string) int {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(cli.Stdout, "usage: netstar [command] [flags]")
+	fmt.Fprintln(cli.Stdout, "\nwithout a command netstar starts the web server.\n\ncommands:")
+	tw := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(cli.Stdout, "\n"+strings.TrimSpace(`
+the tmdb commands accept --output table|json|yaml, --lang and --adult.
+exit codes: 0 ok, 1 error, 2 invalid usage, 3 not found`))
+	return exitOK
+}
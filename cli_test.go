@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+// starts a fake themoviedb api and points the cli to it
+func MockTMDB(t *testing.T, handler http.HandlerFunc) {
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+	t.Setenv("API_URL", mockServer.URL)
+	t.Setenv("API_KEY", "1234")
+}
+
+func RunMockCLI(args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := RunCLI(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLIConfigFromEnvironment(t *testing.T) {
+
+	t.Setenv("REGION", "DE")
+	t.Setenv("MAX_CERTIFICATION", "12")
+	t.Setenv("SECURE_COOKIES", "true")
+	t.Setenv("IMAGE_CACHE_SIZE", "50")
+	t.Setenv("WEBHOOK_URLS", "https://example.com/hook")
+
+	config := LoadCLIConfig(t.TempDir())
+
+	assert.Equal(t, "DE", config.Region)
+	assert.Equal(t, "12", config.MaxCertification)
+	assert.True(t, config.SecureCookies)
+	assert.Equal(t, int64(50), config.ImageCacheSize)
+	assert.Equal(t, []string{"https://example.com/hook"}, config.webhookURLs())
+}
+
+func TestSearchCommandWithJSONOutput(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search/tv", r.URL.Path)
+		assert.Equal(t, "Dark", r.URL.Query().Get("query"))
+		assert.Equal(t, "en-US", r.URL.Query().Get("language"))
+		assert.Equal(t, "true", r.URL.Query().Get("include_adult"))
+		w.Write([]byte(`{"page":1,"total_pages":1,"total_results":1,"results":[{"id":70523,"name":"Dark"}]}`))
+	})
+
+	code, stdout, _ := RunMockCLI("search", "Dark", "--output", "json", "--lang", "en-US", "--adult")
+
+	assert.Equal(t, exitOK, code)
+
+	results := &themoviedb.Results{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), results), "output should be valid json")
+	assert.Equal(t, 70523, results.Results[0].ID)
+}
+
+func TestSearchCommandWithoutResults(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"page":1,"total_pages":0,"total_results":0,"results":[]}`))
+	})
+
+	code, _, _ := RunMockCLI("search", "nothing to find")
+
+	assert.Equal(t, exitNotFound, code)
+}
+
+func TestShowCommandWithTableOutput(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tv/70523", r.URL.Path)
+		w.Write([]byte(`{"id":70523,"name":"Dark","seasons":[{"season_number":1,"name":"Season 1","episode_count":10}]}`))
+	})
+
+	code, stdout, _ := RunMockCLI("show", "70523")
+
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "Dark")
+	assert.Contains(t, stdout, "Season 1")
+}
+
+func TestSeasonCommandWithYAMLOutput(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tv/70523/season/1", r.URL.Path)
+		w.Write([]byte(`{"name":"Season 1","season_number":1,"episodes":[{"episode_number":3,"name":"Past and Present"}]}`))
+	})
+
+	code, stdout, _ := RunMockCLI("season", "70523", "1", "--output=yaml")
+
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "name: Past and Present")
+	assert.Contains(t, stdout, "season_number: 1")
+}
+
+func TestEpisodeCommandNotFound(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"status_code":34,"status_message":"The resource you requested could not be found."}`))
+	})
+
+	code, _, stderr := RunMockCLI("episode", "70523", "1", "99")
+
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, "could not be found")
+}
+
+func TestEpisodeCommandWithUpstreamError(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"success":false,"status_code":7,"status_message":"Invalid API key: You must be granted a valid key."}`))
+	})
+
+	code, _, _ := RunMockCLI("episode", "70523", "1", "3")
+
+	assert.Equal(t, exitError, code)
+}
+
+func TestCommandUsage(t *testing.T) {
+
+	code, _, _ := RunMockCLI("season", "70523")
+	assert.Equal(t, exitUsage, code, "missing season number should be a usage error")
+
+	code, _, _ = RunMockCLI("show", "70523", "--output", "xml")
+	assert.Equal(t, exitUsage, code, "unknown output format should be a usage error")
+
+	code, _, _ = RunMockCLI("unknown")
+	assert.Equal(t, exitUsage, code, "unknown command should be a usage error")
+
+	code, stdout, _ := RunMockCLI("help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "episode <id> <season> <episode>")
+}
+
+func TestParseArgs(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	output := fs.String("output", "table", "")
+
+	positional, err := parseArgs(fs, strings.Fields("70523 --output json 1 3"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"70523", "1", "3"}, positional)
+	assert.Equal(t, "json", *output)
+}
+
+func TestResolveCommand(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"id":70523,"name":"Dark","first_air_date":"2017-12-01"}]}`))
+	})
+
+	input := filepath.Join(t.TempDir(), "titles.csv")
+	os.WriteFile(input, []byte("title,year\nDark,2017\n"), 0644)
+
+	code, stdout, stderr := RunMockCLI("resolve", input, "--output", "json", "--concurrency", "2")
+
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"tmdb_id": 70523`)
+	assert.Contains(t, stderr, "resolved 1 titles, 0 need review, 0 failed")
+}
+
+func TestExportAndImportCommands(t *testing.T) {
+
+	MockTMDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+	})
+	database := filepath.Join(t.TempDir(), "netstar.db")
+	t.Setenv("DATABASE", database)
+
+	db, err := bolt.Open(database, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userStore, _ := users.NewStore(db)
+	userStore.Register("Jonas", "winter is coming")
+	db.Close()
+
+	export := "type,kind,show_id,season,episode,name,rating,text,date\n" +
+		"watched,episode,70523,1,1,,,,2021-03-04T20:00:00Z\n" +
+		"rating,show,70523,,,Dark,10,,2021-03-04T20:00:00Z\n"
+	input := filepath.Join(t.TempDir(), "export.csv")
+	os.WriteFile(input, []byte(export), 0644)
+
+	code, stdout, _ := RunMockCLI("import", "jonas", input)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "restored 0 watchlist entries, 1 watched episodes and 1 ratings for Jonas\n", stdout)
+
+	code, stdout, _ = RunMockCLI("export", "jonas", "--output", "csv")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, strings.Replace(export, "1,1,,", "1,1,Dark,", 1), stdout, "watched shows should be named")
+
+	code, stdout, _ = RunMockCLI("export", "jonas")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"username": "Jonas"`)
+
+	code, _, _ = RunMockCLI("export", "martha")
+	assert.Equal(t, exitError, code)
+
+	code, _, _ = RunMockCLI("export", "jonas", "--output", "yaml")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestExportCommandWhileServerRuns(t *testing.T) {
+
+	database := filepath.Join(t.TempDir(), "netstar.db")
+	t.Setenv("DATABASE", database)
+	db, err := bolt.Open(database, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	code, _, stderr := RunMockCLI("export", "jonas")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "stop the server first")
+	assert.Less(t, time.Since(start), 5*time.Second, "the cli should not wait for the server")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"bereths.com/netstar/backup"
+	"bereths.com/netstar/users"
+	"github.com/gorilla/mux"
+)
+
+// downloads the watchlist, watched episodes and ratings of the logged in user as json or csv
+func ExportHandler(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		data, err := backup.New(app.TMDB, app.Watchlist, app.Progress, app.Ratings).Export(user.ID, user.Username)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		format := mux.Vars(r)["format"]
+		w.Header().Set("Content-Disposition", `attachment; filename="netstar-`+user.Username+`.`+format+`"`)
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			err = backup.WriteCSV(w, data)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = backup.WriteJSON(w, data)
+		}
+		if err != nil {
+			log.Printf("Could not export %s: %v", user.Username, err)
+		}
+	}
+}
+
+// adds an uploaded netstar export to the logged in user
+func RestoreHandler(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, importPage, &ImportPage{Error: errorMessage(errNoExport)})
+			return
+		}
+		defer file.Close()
+
+		data, err := backup.Read(file)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, importPage, &ImportPage{Error: err.Error()})
+			return
+		}
+		if err := data.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, importPage, &ImportPage{Error: err.Error()})
+			return
+		}
+
+		report, err := backup.New(app.TMDB, app.Watchlist, app.Progress, app.Ratings).Restore(user.ID, user.Username, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		render(w, r, importPage, &ImportPage{Restored: report})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"bereths.com/netstar/backup"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportAndRestore(t *testing.T) {
+
+	mockServer, client, app := GetAccountServer(t, GetValidClient())
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/import")
+
+	export := `{"version":1,"username":"martha","watchlist":[{"show_id":70523,"name":"Dark","added":"2021-03-04T20:00:00Z"}],
+		"watched":[{"show_id":70523,"name":"Dark","season":1,"episode":1,"watched_at":"2021-03-04T20:00:00Z"}],
+		"ratings":[{"kind":"show","show_id":70523,"name":"Dark","rating":10,"updated":"2021-03-04T20:00:00Z"}]}`
+
+	resp := PostImport(t, client, mockServer.URL+"/import/backup", token, "netstar-martha.json", export)
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Restored 1 shows on your watchlist, 1 watched episodes and 1 ratings")
+
+	resp, _ = client.Get(mockServer.URL + "/export.json")
+	assert.Equal(t, `attachment; filename="netstar-Jonas.json"`, resp.Header.Get("Content-Disposition"))
+	data := &backup.Data{}
+	json.NewDecoder(resp.Body).Decode(data)
+	resp.Body.Close()
+	assert.Equal(t, "Jonas", data.Username)
+	assert.Len(t, data.Watched, 1)
+	if assert.Len(t, data.Ratings, 1) {
+		assert.Equal(t, 10, data.Ratings[0].Rating)
+	}
+
+	resp, _ = client.Get(mockServer.URL + "/export.csv")
+	body = ReadBody(t, resp)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, "watchlist,show,70523,,,Dark,,,2021-03-04T20:00:00Z")
+
+	entries, _ := app.Watchlist.List(1)
+	assert.Equal(t, []watchlist.Entry{{ShowID: 70523, Name: "Dark", Added: data.Watchlist[0].Added}}, entries)
+}
+
+func TestRestoreWithInvalidExport(t *testing.T) {
+
+	mockServer, client, app := GetAccountServer(t, GetValidClient())
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/import")
+
+	resp := PostImport(t, client, mockServer.URL+"/import/backup", token, "export.json", `{"version":1,"watchlist":[{"show_id":70523}],"ratings":[{"kind":"show","show_id":70523,"rating":42}]}`)
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, body, "rating must be between 1 and 10")
+	assert.False(t, app.Watchlist.Has(1, 70523))
+}
+
+func TestExportRequiresLogin(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+
+	resp, _ := client.Get(mockServer.URL + "/export.json")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"github.com/gorilla/mux"
+)
+
+// the sources in /find/{source}/{id} and the names themoviedb knows them by
+var findSources = map[string]string{
+	"imdb":     themoviedb.IMDbID,
+	"tvdb":     themoviedb.TVDBID,
+	"wikidata": themoviedb.WikidataID,
+}
+
+var errNothingFound = errors.New("no tv show, season or episode has this id")
+
+// the show, season or episode an external id belongs to
+type Resolved struct {
+	ratings.Target
+	Name string `json:"name"`
+	// the page on netstar
+	Path string `json:"path"`
+}
+
+// looks the id up on themoviedb, episodes win over seasons over shows
+func resolveExternalID(themoviedbAPI *themoviedb.Client, source, id string) (*Resolved, error) {
+	found, err := themoviedbAPI.Find(id, findSources[source])
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved *Resolved
+	switch {
+	case len(found.TVEpisodeResults) > 0:
+		e := found.TVEpisodeResults[0]
+		resolved = &Resolved{Target: ratings.Target{Kind: ratings.Episode, ShowID: e.ShowID, Season: e.SeasonNumber, Episode: e.EpisodeNumber}, Name: e.Name}
+	case len(found.TVSeasonResults) > 0:
+		s := found.TVSeasonResults[0]
+		resolved = &Resolved{Target: ratings.Target{Kind: ratings.Season, ShowID: s.ShowID, Season: s.SeasonNumber}, Name: s.Name}
+	case len(found.TVResults) > 0:
+		s := found.TVResults[0]
+		resolved = &Resolved{Target: ratings.Target{Kind: ratings.Show, ShowID: s.ID}, Name: s.Name}
+	default:
+		return nil, errNothingFound
+	}
+	resolved.Path = resolved.Target.Path()
+	return resolved, nil
+}
+
+// redirects an id of imdb, tvdb or wikidata to its page, like /find/imdb/tt5753856
+func FindHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		resolved, err := resolveExternalID(themoviedbAPI, vars["source"], vars["id"])
+		if err == errNothingFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		http.Redirect(w, r, resolved.Path, http.StatusFound)
+	}
+}
+
+// the show, season or episode of an external id as json
+func APIFindHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		resolved, err := resolveExternalID(themoviedbAPI, vars["source"], vars["id"])
+		if err == errNothingFound {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeJSONError(w, errorStatus(err), err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resolved)
+	}
+}
+
+// the details of a show with its external ids
+func APIShowHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		show, err := themoviedbAPI.GetTVShowDetails(mux.Vars(r)["id"])
+		if err != nil {
+			writeJSONError(w, errorStatus(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, show)
+	}
+}
+
+// the details of an episode with its external ids
+func APIEpisodeHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		episode, err := themoviedbAPI.GetEpisodeDetails(vars["id"], vars["season"], vars["episode"])
+		if err != nil {
+			writeJSONError(w, errorStatus(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, episode)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// the app around a themoviedb knowing dark by its imdb, tvdb and wikidata ids
+func GetExternalIDServer(t *testing.T) *httptest.Server {
+	themoviedbAPI := themoviedbtest.NewClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/find/tt5753856":
+			w.Write([]byte(`{"tv_results":[{"id":70523,"name":"Dark"}],"tv_season_results":[],"tv_episode_results":[]}`))
+		case "/find/6384958":
+			assert.Equal(t, "tvdb_id", r.URL.Query().Get("external_source"))
+			w.Write([]byte(`{"tv_results":[],"tv_episode_results":[{"id":1,"name":"Secrets","show_id":70523,"season_number":1,"episode_number":1}]}`))
+		case "/find/Q1":
+			w.Write([]byte(`{"movie_results":[{"id":1}],"tv_results":[]}`))
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","external_ids":{"imdb_id":"tt5753856","tvdb_id":334824,"wikidata_id":"Q30894592"}}`))
+		case "/tv/70523/season/1/episode/1":
+			w.Write([]byte(`{"id":1,"name":"Secrets","season_number":1,"episode_number":1,"external_ids":{"imdb_id":"tt6305578","tvdb_id":6384958}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI}))
+	t.Cleanup(mockServer.Close)
+	return mockServer
+}
+
+func TestFindRedirectsToPage(t *testing.T) {
+
+	mockServer := GetExternalIDServer(t)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, _ := client.Get(mockServer.URL + "/find/imdb/tt5753856")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/details?id=70523", resp.Header.Get("Location"))
+
+	resp, _ = client.Get(mockServer.URL + "/find/tvdb/6384958")
+	resp.Body.Close()
+	assert.Equal(t, "/details/episode?id=70523&seasonNumber=1&episodeNumber=1", resp.Header.Get("Location"))
+
+	resp, _ = client.Get(mockServer.URL + "/find/wikidata/Q1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "movies should not be found")
+
+	resp, _ = client.Get(mockServer.URL + "/find/netflix/1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIFind(t *testing.T) {
+
+	mockServer := GetExternalIDServer(t)
+
+	resp, _ := http.Get(mockServer.URL + "/api/find/tvdb/6384958")
+	resolved := &Resolved{}
+	json.NewDecoder(resp.Body).Decode(resolved)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "episode", resolved.Kind)
+	assert.Equal(t, "Secrets", resolved.Name)
+	assert.Equal(t, "/details/episode?id=70523&seasonNumber=1&episodeNumber=1", resolved.Path)
+
+	resp, _ = http.Get(mockServer.URL + "/api/find/wikidata/Q1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIDetailsWithExternalIDs(t *testing.T) {
+
+	mockServer := GetExternalIDServer(t)
+
+	resp, _ := http.Get(mockServer.URL + "/api/shows/70523")
+	body := ReadBody(t, resp)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `"imdb_id":"tt5753856"`)
+
+	resp, _ = http.Get(mockServer.URL + "/api/shows/70523/seasons/1/episodes/1")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, `"tvdb_id":6384958`)
+
+	resp, _ = http.Get(mockServer.URL + "/api/shows/1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDetailsLinkExternalIDs(t *testing.T) {
+
+	mockServer := GetExternalIDServer(t)
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, "https://www.imdb.com/title/tt5753856/")
+	assert.Contains(t, body, "https://thetvdb.com/dereferrer/series/334824")
+	assert.Contains(t, body, "https://www.wikidata.org/wiki/Q30894592")
+
+	resp, _ = http.Get(mockServer.URL + "/details?id=1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unknown shows should not be an error of the server")
+
+	resp, _ = http.Get(mockServer.URL + "/details/episode?id=70523&seasonNumber=1&episodeNumber=1")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "https://thetvdb.com/dereferrer/episode/6384958")
+	assert.NotContains(t, body, "wikidata")
+}
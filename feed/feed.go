@@ -0,0 +1,115 @@
+// Package feed lists the recently aired episodes of shows as Atom and RSS feeds.
+package feed
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// how many aired episodes of a show are looked at
+const PerShow = 10
+
+// the most episodes a feed lists
+const MaxItems = 50
+
+// an aired episode
+type Item struct {
+	ShowID   int
+	ShowName string
+	Season   int
+	Episode  int
+	Name     string
+	Overview string
+	AirDate  string
+}
+
+// the time the episode aired, midnight utc as themoviedb only knows the day
+func (i Item) Aired() time.Time {
+	t, _ := time.Parse("2006-01-02", i.AirDate)
+	return t
+}
+
+// the globally unique and stable id of the episode, a tag uri (RFC 4151)
+func (i Item) GUID() string {
+	return fmt.Sprintf("tag:netstar,2022:tv/%d/s%02de%02d", i.ShowID, i.Season, i.Episode)
+}
+
+func (i Item) Title() string {
+	title := fmt.Sprintf("%s S%02dE%02d", i.ShowName, i.Season, i.Episode)
+	if i.Name != "" {
+		title += " " + i.Name
+	}
+	return title
+}
+
+// the episode page on netstar
+func (i Item) Path() string {
+	return fmt.Sprintf("/details/episode?id=%d&seasonNumber=%d&episodeNumber=%d", i.ShowID, i.Season, i.Episode)
+}
+
+// the episodes of the shows that aired until today (formatted like 2006-01-02), newest first.
+// shows that fail to load are logged and left out
+func Recent(client *themoviedb.Client, showIDs []int, today string) []Item {
+	var mu sync.Mutex
+	items := []Item{}
+
+	themoviedb.Parallel(len(showIDs), func(i int) {
+		found, err := recent(client, strconv.Itoa(showIDs[i]), today)
+		if err != nil {
+			log.Printf("Could not load aired episodes of %d: %v", showIDs[i], err)
+			return
+		}
+		mu.Lock()
+		items = append(items, found...)
+		mu.Unlock()
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].AirDate != items[j].AirDate {
+			return items[i].AirDate > items[j].AirDate
+		}
+		if items[i].ShowID != items[j].ShowID {
+			return items[i].ShowID < items[j].ShowID
+		}
+		if items[i].Season != items[j].Season {
+			return items[i].Season > items[j].Season
+		}
+		return items[i].Episode > items[j].Episode
+	})
+	if len(items) > MaxItems {
+		items = items[:MaxItems]
+	}
+	return items
+}
+
+// the last PerShow aired episodes, starting at the season of the last episode and going
+// back a season if it has fewer episodes
+func recent(client *themoviedb.Client, id, today string) ([]Item, error) {
+	show, err := client.GetTVShowDetails(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for n := show.LastEpisodeToAir.SeasonNumber; n > 0 && n >= show.LastEpisodeToAir.SeasonNumber-1 && len(items) < PerShow; n-- {
+		season, err := client.GetSeasonDetails(id, strconv.Itoa(n))
+		if err != nil {
+			return nil, err
+		}
+
+		for i := len(season.Episodes) - 1; i >= 0 && len(items) < PerShow; i-- {
+			ep := season.Episodes[i]
+			if ep.AirDate == "" || ep.AirDate > today {
+				continue
+			}
+			items = append(items, Item{show.ID, show.Name, n, ep.EpisodeNumber, ep.Name, ep.Overview, ep.AirDate})
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,61 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// dark has aired season 1 with 8 and the first 3 episodes of season 2, another show failed
+func GetMockClient(t *testing.T) *themoviedb.Client {
+	return themoviedbtest.NewClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","last_episode_to_air":{"season_number":2,"episode_number":3}}`))
+		case "/tv/70523/season/1":
+			body := `{"season_number":1,"episodes":[`
+			for n := 1; n <= 8; n++ {
+				if n > 1 {
+					body += ","
+				}
+				body += fmt.Sprintf(`{"episode_number":%d,"air_date":"2017-12-%02d"}`, n, n)
+			}
+			w.Write([]byte(body + "]}"))
+		case "/tv/70523/season/2":
+			w.Write([]byte(`{"season_number":2,"episodes":[
+				{"episode_number":1,"air_date":"2019-06-01"},
+				{"episode_number":2,"air_date":"2019-06-02"},
+				{"episode_number":3,"air_date":"2019-06-03"},
+				{"episode_number":4,"air_date":"2999-01-01"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestRecent(t *testing.T) {
+
+	items := Recent(GetMockClient(t), []int{70523, 404}, "2022-01-01")
+
+	assert.Len(t, items, PerShow)
+	assert.Equal(t, Item{ShowID: 70523, ShowName: "Dark", Season: 2, Episode: 3, AirDate: "2019-06-03"}, items[0], "newest aired episode should come first")
+	assert.Equal(t, "2017-12-02", items[PerShow-1].AirDate, "should go back to the previous season")
+}
+
+func TestRecentBoundsConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	client := themoviedbtest.NewClient(t, concurrency.Slow(http.NotFound))
+
+	ids := make([]int, 30)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	assert.Empty(t, Recent(client, ids, "2022-01-01"))
+
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
@@ -0,0 +1,143 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// a feed of items. links are absolute, as feed readers fetch them from elsewhere
+type Feed struct {
+	Title string
+	// the html page the feed belongs to
+	Link string
+	// the url of the feed itself
+	Self string
+	// where the paths of the items are resolved against, like https://netstar.example.com
+	BaseURL string
+	Items   []Item
+}
+
+// the air date of the newest item, or the zero time if there are none
+func (f *Feed) Updated() time.Time {
+	var updated time.Time
+	for _, item := range f.Items {
+		if aired := item.Aired(); aired.After(updated) {
+			updated = aired
+		}
+	}
+	return updated
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Updated   string   `xml:"updated"`
+	Published string   `xml:"published"`
+	Link      atomLink `xml:"link"`
+	Summary   string   `xml:"summary,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// writes the feed as Atom (RFC 4287)
+func (f *Feed) WriteAtom(w io.Writer) error {
+	feed := &atomFeed{
+		ID:      f.Self,
+		Title:   f.Title,
+		Updated: atomTime(f.Updated()),
+		Author:  "netstar",
+		Links: []atomLink{
+			{Rel: "alternate", Type: "text/html", Href: f.Link},
+			{Rel: "self", Type: "application/atom+xml", Href: f.Self},
+		},
+	}
+	for _, item := range f.Items {
+		aired := atomTime(item.Aired())
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        item.GUID(),
+			Title:     item.Title(),
+			Updated:   aired,
+			Published: aired,
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: f.BaseURL + item.Path()},
+			Summary:   item.Overview,
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Atom    string   `xml:"xmlns:atom,attr"`
+	Channel struct {
+		Title         string    `xml:"channel>title"`
+		Link          string    `xml:"channel>link"`
+		Self          atomLink  `xml:"channel>atom:link"`
+		Description   string    `xml:"channel>description"`
+		LastBuildDate string    `xml:"channel>lastBuildDate,omitempty"`
+		Items         []rssItem `xml:"channel>item"`
+	}
+}
+
+// writes the feed as RSS 2.0
+func (f *Feed) WriteRSS(w io.Writer) error {
+	feed := &rssFeed{Version: "2.0", Atom: "http://www.w3.org/2005/Atom"}
+	feed.Channel.Title = f.Title
+	feed.Channel.Link = f.Link
+	feed.Channel.Self = atomLink{Rel: "self", Type: "application/rss+xml", Href: f.Self}
+	feed.Channel.Description = f.Title
+	if updated := f.Updated(); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	for _, item := range f.Items {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Title(),
+			Link:        f.BaseURL + item.Path(),
+			GUID:        rssGUID{false, item.GUID()},
+			PubDate:     item.Aired().Format(time.RFC1123Z),
+			Description: item.Overview,
+		})
+	}
+	return writeXML(w, feed)
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,56 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockFeed() *Feed {
+	return &Feed{
+		Title:   "Dark",
+		Link:    "http://localhost/details?id=70523",
+		Self:    "http://localhost/feeds/shows/70523.atom",
+		BaseURL: "http://localhost",
+		Items: []Item{
+			{ShowID: 70523, ShowName: "Dark", Season: 3, Episode: 8, Name: "Paradise", Overview: "Adam & Eve", AirDate: "2020-06-27"},
+			{ShowID: 70523, ShowName: "Dark", Season: 3, Episode: 7, Name: "The Origin", AirDate: "2020-06-26"},
+		},
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, GetMockFeed().WriteAtom(buf))
+
+	var feed atomFeed
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &feed), "should be valid xml")
+	assert.Equal(t, "2020-06-27T00:00:00Z", feed.Updated, "feed should be as new as its newest entry")
+	assert.Len(t, feed.Entries, 2)
+	assert.Equal(t, "tag:netstar,2022:tv/70523/s03e08", feed.Entries[0].ID)
+	assert.Equal(t, "Dark S03E08 Paradise", feed.Entries[0].Title)
+	assert.Equal(t, "http://localhost/details/episode?id=70523&seasonNumber=3&episodeNumber=8", feed.Entries[0].Link.Href)
+	assert.Contains(t, buf.String(), "Adam &amp; Eve")
+}
+
+func TestWriteRSS(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, GetMockFeed().WriteRSS(buf))
+
+	var feed rssFeed
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &feed), "should be valid xml")
+	assert.Equal(t, "Dark", feed.Channel.Title)
+	assert.Len(t, feed.Channel.Items, 2)
+	assert.Equal(t, "Sat, 27 Jun 2020 00:00:00 +0000", feed.Channel.Items[0].PubDate)
+	assert.Contains(t, buf.String(), `<guid isPermaLink="false">tag:netstar,2022:tv/70523/s03e08</guid>`)
+	assert.Contains(t, buf.String(), `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`)
+}
+
+func TestUpdatedWithoutItems(t *testing.T) {
+
+	assert.True(t, (&Feed{}).Updated().IsZero())
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bereths.com/netstar/feed"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+	"github.com/gorilla/mux"
+)
+
+// how long feed readers may keep a feed
+const feedMaxAge = time.Hour
+
+// the recently aired episodes of a show like /feeds/shows/70523.atom
+func ShowFeedHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, _ := strconv.Atoi(vars["id"])
+
+		show, err := themoviedbAPI.GetTVShowDetails(vars["id"])
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		f := &feed.Feed{
+			Title:   show.Name,
+			Link:    absoluteURL(r, fmt.Sprintf("/details?id=%d", id)),
+			Self:    absoluteURL(r, r.URL.Path),
+			BaseURL: absoluteURL(r, ""),
+			Items:   feed.Recent(themoviedbAPI, []int{id}, time.Now().Format("2006-01-02")),
+		}
+		// the texts depend on the language and adult preferences of the reader, shared caches must not mix them up
+		serveFeed(w, r, f, vars["format"], "private")
+	}
+}
+
+// the recently aired episodes of the user's watchlist, found by the secret feed token
+func WatchlistFeedHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		user, err := manager.Store.FeedUser(vars["token"])
+		if err == users.ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		entries, err := wl.List(user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ids := make([]int, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ShowID
+		}
+
+		f := &feed.Feed{
+			Title:   "Watchlist of " + user.Username,
+			Link:    absoluteURL(r, "/watchlist"),
+			Self:    absoluteURL(r, r.URL.Path),
+			BaseURL: absoluteURL(r, ""),
+			Items:   feed.Recent(themoviedbAPI, ids, time.Now().Format("2006-01-02")),
+		}
+		// the url is a secret, so shared caches must not keep it
+		serveFeed(w, r, f, vars["format"], "private")
+	}
+}
+
+// writes the feed as atom or rss. ServeContent answers conditional requests of feed
+// readers with 304 Not Modified, based on the etag and the newest episode
+func serveFeed(w http.ResponseWriter, r *http.Request, f *feed.Feed, format, cache string) {
+	buf := &bytes.Buffer{}
+	var err error
+	if format == "rss" {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		err = f.WriteRSS(buf)
+	} else {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		err = f.WriteAtom(buf)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", cache, int(feedMaxAge.Seconds())))
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(buf.Bytes())))
+	http.ServeContent(w, r, "", f.Updated(), bytes.NewReader(buf.Bytes()))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+)
+
+// a themoviedb where dark aired its first season
+func GetMockFeedServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","last_episode_to_air":{"season_number":1,"episode_number":2}}`))
+		case "/tv/70523/season/1":
+			w.Write([]byte(`{"season_number":1,"episodes":[{"episode_number":1,"name":"Secrets","air_date":"2017-12-01"},{"episode_number":2,"name":"Lies","air_date":"2017-12-02"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestShowFeed(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockFeedServer(t).URL)
+	mockServer := httptest.NewServer(NewRouter(themoviedbAPI))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/feeds/shows/70523.atom")
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "private, max-age=3600", resp.Header.Get("Cache-Control"))
+	assert.Contains(t, resp.Header.Get("Vary"), "Cookie")
+	assert.Equal(t, "Sat, 02 Dec 2017 00:00:00 GMT", resp.Header.Get("Last-Modified"))
+	assert.Contains(t, body, "<id>tag:netstar,2022:tv/70523/s01e02</id>")
+	assert.Contains(t, body, mockServer.URL+"/details/episode?id=70523&amp;seasonNumber=1&amp;episodeNumber=2")
+
+	// feed readers ask if something changed
+	req, _ := http.NewRequest("GET", mockServer.URL+"/feeds/shows/70523.rss", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	etag := resp.Header.Get("ETag")
+	assert.Contains(t, ReadBody(t, resp), "<title>Dark S01E01 Secrets</title>")
+
+	req.Header.Set("If-None-Match", etag)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, _ = http.Get(mockServer.URL + "/feeds/shows/1.atom")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+var watchlistFeedURL = regexp.MustCompile(`href="(http://[^"]+\.atom)"`)
+
+func TestWatchlistFeed(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockFeedServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	all, _ := app.Users.Store.All()
+	app.Watchlist.Add(all[0].ID, watchlist.Entry{ShowID: 70523, Name: "Dark"})
+
+	resp, _ := client.Get(mockServer.URL + "/watchlist")
+	m := watchlistFeedURL.FindStringSubmatch(ReadBody(t, resp))
+	if m == nil {
+		t.Fatal("no feed url on watchlist page")
+	}
+
+	resp, _ = http.Get(m[1])
+	assert.Equal(t, "private, max-age=3600", resp.Header.Get("Cache-Control"))
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, "Watchlist of Jonas")
+	assert.Contains(t, body, "Dark S01E02 Lies")
+
+	resp, _ = http.Get(mockServer.URL + "/feeds/watchlist/unknown.atom")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// the urls are only shown once, new ones can be created from the watchlist
+	token := GetCSRFToken(t, client, mockServer.URL+"/watchlist")
+	resp, _ = client.Get(mockServer.URL + "/watchlist")
+	body = ReadBody(t, resp)
+	assert.NotContains(t, body, m[1])
+	assert.Contains(t, body, `action="/calendar/reset"`)
+
+	resp, _ = client.PostForm(mockServer.URL+"/calendar/reset", url.Values{"csrf_token": {token}, "next": {"/watchlist"}})
+	assert.Equal(t, "/watchlist", resp.Request.URL.Path)
+	renewed := watchlistFeedURL.FindStringSubmatch(ReadBody(t, resp))
+	if assert.NotNil(t, renewed, "no new feed url on watchlist page") {
+		assert.NotEqual(t, m[1], renewed[1])
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+)
+
+// an image of a gallery with the sizes the browser can choose from
+type GalleryImage struct {
+	// the original file, linked to
+	URL      string
+	Src      string
+	SrcSet   string
+	Width    int
+	Height   int
+	Language string
+}
+
+// the images of one kind, like the posters
+type GallerySection struct {
+	// the message id of the kind
+	Title  string
+	Sizes  string
+	Images []GalleryImage
+}
+
+// a link to the gallery in one language
+type GalleryFilter struct {
+	// the language, empty for all images
+	Label  string
+	URL    string
+	Active bool
+}
+
+// the images of a show, season or episode
+type GalleryPage struct {
+	// the page of the show, season or episode
+	BackPath string
+	Filters  []GalleryFilter
+	Sections []GallerySection
+}
+
+// the default size and the sizes attribute of the kinds of images in the gallery
+var galleryKinds = map[string]struct{ src, sizes string }{
+	themoviedb.PosterImage:   {"w342", "(max-width: 768px) 50vw, 20vw"},
+	themoviedb.BackdropImage: {"w780", "(max-width: 768px) 100vw, 50vw"},
+	themoviedb.LogoImage:     {"w300", "(max-width: 768px) 50vw, 20vw"},
+	themoviedb.StillImage:    {"w300", "(max-width: 768px) 100vw, 33vw"},
+}
+
+func gallerySection(title, kind string, images []themoviedb.Image) GallerySection {
+	section := GallerySection{Title: title, Sizes: galleryKinds[kind].sizes}
+	for _, image := range images {
+		section.Images = append(section.Images, GalleryImage{
+			URL:      imageURL("original", image.FilePath),
+			Src:      imageURL(galleryKinds[kind].src, image.FilePath),
+			SrcSet:   themoviedb.SrcSet(imagePath, kind, image.FilePath),
+			Width:    image.Width,
+			Height:   image.Height,
+			Language: image.Iso6391,
+		})
+	}
+	return section
+}
+
+// shows the images of a show, season or episode like /details/images?id=70523&seasonNumber=1&lang=de
+func GalleryHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		id, seasonNumber, episodeNumber := params.Get("id"), params.Get("seasonNumber"), params.Get("episodeNumber")
+
+		target := ratings.Target{Kind: ratings.Show}
+		target.ShowID, _ = strconv.Atoi(id)
+		var images *themoviedb.Images
+		var err error
+		switch {
+		case episodeNumber != "":
+			target.Kind = ratings.Episode
+			target.Season, _ = strconv.Atoi(seasonNumber)
+			target.Episode, _ = strconv.Atoi(episodeNumber)
+			images, err = themoviedbAPI.GetEpisodeImages(id, seasonNumber, episodeNumber)
+		case seasonNumber != "":
+			target.Kind = ratings.Season
+			target.Season, _ = strconv.Atoi(seasonNumber)
+			images, err = themoviedbAPI.GetSeasonImages(id, seasonNumber)
+		default:
+			images, err = themoviedbAPI.GetTVShowImages(id)
+		}
+		if err == nil && !target.Valid() {
+			err = errInvalidID
+		}
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		lang := params.Get("lang")
+		filtered := images.Filter(lang)
+		page := &GalleryPage{BackPath: target.Path()}
+		for _, filter := range append([]string{""}, images.Languages()...) {
+			query := url.Values{}
+			for _, key := range []string{"id", "seasonNumber", "episodeNumber"} {
+				if value := params.Get(key); value != "" {
+					query.Set(key, value)
+				}
+			}
+			if filter != "" {
+				query.Set("lang", filter)
+			}
+			page.Filters = append(page.Filters, GalleryFilter{filter, "/details/images?" + query.Encode(), filter == lang})
+		}
+		for _, section := range []GallerySection{
+			gallerySection("gallery.posters", themoviedb.PosterImage, filtered.Posters),
+			gallerySection("gallery.backdrops", themoviedb.BackdropImage, filtered.Backdrops),
+			gallerySection("gallery.logos", themoviedb.LogoImage, filtered.Logos),
+			gallerySection("gallery.stills", themoviedb.StillImage, filtered.Stills),
+		} {
+			if len(section.Images) > 0 {
+				page.Sections = append(page.Sections, section)
+			}
+		}
+
+		render(w, r, galleryPage, page)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GetGalleryServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","poster_path":"/poster.jpg","backdrop_path":"/backdrop.jpg"}`))
+		case "/tv/70523/images":
+			w.Write([]byte(`{"id":70523,"backdrops":[{"file_path":"/b.jpg","iso_639_1":null,"width":1920,"height":1080}],
+				"posters":[{"file_path":"/de.jpg","iso_639_1":"de","width":1000,"height":1500},{"file_path":"/en.jpg","iso_639_1":"en","width":1000,"height":1500}]}`))
+		case "/tv/70523/season/1/episode/1/images":
+			w.Write([]byte(`{"id":1,"stills":[{"file_path":"/still.jpg","width":1920,"height":1080}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI}))
+	t.Cleanup(mockServer.Close)
+	return mockServer
+}
+
+func TestGalleryFiltersByLanguage(t *testing.T) {
+
+	mockServer := GetGalleryServer(t)
+
+	resp, _ := http.Get(mockServer.URL + "/details/images?id=70523")
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "/de.jpg")
+	assert.Contains(t, body, "/en.jpg")
+	assert.Contains(t, body, "/img/w154/de.jpg 154w")
+	assert.Contains(t, body, `href="/details/images?id=70523&amp;lang=de"`)
+	assert.Contains(t, body, `href="/details?id=70523"`)
+
+	resp, _ = http.Get(mockServer.URL + "/details/images?id=70523&lang=de")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "/de.jpg")
+	assert.NotContains(t, body, "/en.jpg")
+	assert.Contains(t, body, "/b.jpg", "images without language should be shown in every language")
+
+	resp, _ = http.Get(mockServer.URL + "/details/images?id=70523&seasonNumber=1&episodeNumber=1")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "Stills")
+	assert.Contains(t, body, "/img/w300/still.jpg")
+
+	resp, _ = http.Get(mockServer.URL + "/details/images?id=1")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDetailsHaveBackdropHeader(t *testing.T) {
+
+	mockServer := GetGalleryServer(t)
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+
+	assert.Contains(t, body, `class="image backdrop-header"`)
+	assert.Contains(t, body, "/img/w1280/backdrop.jpg 1280w")
+	assert.Contains(t, body, "/img/w780/poster.jpg 780w")
+}
@@ -0,0 +1,180 @@
+// Package i18n translates the texts of the interface. every language has a catalog
+// in a json file named after it, like de.json, mapping message ids to texts.
+// texts depending on a number map the plural forms "one" and "other" to texts instead.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// the texts of one plural form each, plain messages only have "other"
+type message map[string]string
+
+type catalog map[string]message
+
+// the catalogs of all languages
+type Bundle struct {
+	// the supported languages, the fallback first
+	tags     []language.Tag
+	catalogs []catalog
+	matcher  language.Matcher
+}
+
+// reads every catalog in dir. texts missing in a catalog are taken from the one of fallback
+func Load(dir, fallback string) (*Bundle, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{}
+	for _, path := range paths {
+		tag, err := language.Parse(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		c, err := readCatalog(path)
+		if err != nil {
+			return nil, err
+		}
+		if tag.String() == fallback {
+			b.tags = append([]language.Tag{tag}, b.tags...)
+			b.catalogs = append([]catalog{c}, b.catalogs...)
+		} else {
+			b.tags = append(b.tags, tag)
+			b.catalogs = append(b.catalogs, c)
+		}
+	}
+	if len(b.tags) == 0 || b.tags[0].String() != fallback {
+		return nil, fmt.Errorf("no catalog for %s in %s", fallback, dir)
+	}
+	b.matcher = language.NewMatcher(b.tags)
+	return b, nil
+}
+
+// panics if the catalogs could not be loaded, for package level variables
+func Must(b *Bundle, err error) *Bundle {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func readCatalog(path string) (catalog, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c := catalog{}
+	for id, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			c[id] = message{"other": text}
+			continue
+		}
+		var forms message
+		if err := json.Unmarshal(value, &forms); err != nil || forms["other"] == "" {
+			return nil, fmt.Errorf("%s: %s is neither a text nor plural forms with other", path, id)
+		}
+		c[id] = forms
+	}
+	return c, nil
+}
+
+// the languages there are catalogs for, the fallback first
+func (b *Bundle) Languages() []string {
+	tags := make([]string, len(b.tags))
+	for i, tag := range b.tags {
+		tags[i] = tag.String()
+	}
+	return tags
+}
+
+// the texts in the first of prefs there is a catalog for. a pref is a language like de-DE or
+// an Accept-Language header; if none matches the fallback is used
+func (b *Bundle) Localizer(prefs ...string) *Localizer {
+	index := 0
+	for _, pref := range prefs {
+		accepted, _, err := language.ParseAcceptLanguage(pref)
+		if err != nil || len(accepted) == 0 {
+			continue
+		}
+		if _, i, confidence := b.matcher.Match(accepted...); confidence != language.No {
+			index = i
+			break
+		}
+	}
+	return &Localizer{tag: b.tags[index], messages: b.catalogs[index], fallback: b.catalogs[0]}
+}
+
+// translates message ids into one language
+type Localizer struct {
+	tag      language.Tag
+	messages catalog
+	fallback catalog
+}
+
+// the language of the texts, like de
+func (l *Localizer) Language() string {
+	return l.tag.String()
+}
+
+// the text of id formatted with args. unknown ids are returned as they are,
+// so texts that have no message, like error messages, can be passed through
+func (l *Localizer) T(id string, args ...interface{}) string {
+	return l.format(id, "other", args)
+}
+
+// the plural form of id for n, formatted with n and args
+func (l *Localizer) N(id string, n int, args ...interface{}) string {
+	return l.format(id, pluralForm(l.tag, n), append([]interface{}{n}, args...))
+}
+
+func (l *Localizer) format(id, form string, args []interface{}) string {
+	text := id
+	if m, ok := l.messages[id]; ok {
+		text = m.text(form)
+	} else if m, ok := l.fallback[id]; ok {
+		text = m.text(form)
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+func (m message) text(form string) string {
+	if text, ok := m[form]; ok {
+		return text
+	}
+	return m["other"]
+}
+
+// the plural form of n in the language of tag
+func pluralForm(tag language.Tag, n int) string {
+	base, _ := tag.Base()
+	switch base.String() {
+	case "ja", "zh", "ko":
+		return "other"
+	case "fr", "pt":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
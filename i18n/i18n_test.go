@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func GetBundle(t *testing.T) *Bundle {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{
+		"nav.watchlist": "Watchlist",
+		"nav.login": "Log in",
+		"greeting": "Hello %s",
+		"votes": {"one": "%d vote", "other": "%d votes"}
+	}`), 0644)
+	os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{
+		"nav.watchlist": "Merkliste",
+		"votes": {"one": "%d Stimme", "other": "%d Stimmen"}
+	}`), 0644)
+	os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{
+		"votes": {"one": "%d vote", "other": "%d votes"}
+	}`), 0644)
+	return Must(Load(dir, "en"))
+}
+
+func TestLocalizerTranslates(t *testing.T) {
+
+	bundle := GetBundle(t)
+	assert.Equal(t, []string{"en", "de", "fr"}, bundle.Languages())
+
+	de := bundle.Localizer("de-DE")
+	assert.Equal(t, "de", de.Language())
+	assert.Equal(t, "Merkliste", de.T("nav.watchlist"))
+	assert.Equal(t, "Log in", de.T("nav.login"), "missing texts should be taken from the fallback")
+	assert.Equal(t, "Hello Jonas", de.T("greeting", "Jonas"))
+	assert.Equal(t, "invalid username or password", de.T("invalid username or password"), "unknown ids should be passed through")
+	assert.Equal(t, "100%", de.T("100%"))
+}
+
+func TestLocalizerPluralForms(t *testing.T) {
+
+	bundle := GetBundle(t)
+
+	assert.Equal(t, "1 vote", bundle.Localizer("en").N("votes", 1))
+	assert.Equal(t, "0 votes", bundle.Localizer("en").N("votes", 0))
+	assert.Equal(t, "5123 Stimmen", bundle.Localizer("de").N("votes", 5123))
+	assert.Equal(t, "1 Stimme", bundle.Localizer("de").N("votes", 1))
+	assert.Equal(t, "0 vote", bundle.Localizer("fr").N("votes", 0), "french uses the singular for zero")
+
+	assert.Equal(t, "other", pluralForm(language.Japanese, 1))
+	assert.Equal(t, "one", pluralForm(language.BritishEnglish, 1))
+}
+
+func TestLocalizerNegotiation(t *testing.T) {
+
+	bundle := GetBundle(t)
+
+	assert.Equal(t, "en", bundle.Localizer().Language())
+	assert.Equal(t, "en", bundle.Localizer("ja-JP").Language(), "unsupported languages should fall back")
+	assert.Equal(t, "de", bundle.Localizer("de-AT").Language())
+	assert.Equal(t, "de", bundle.Localizer("ja-JP,de;q=0.8,en;q=0.5").Language())
+	assert.Equal(t, "fr", bundle.Localizer("", "fr-CH, de;q=0.5").Language())
+	assert.Equal(t, "de", bundle.Localizer("de-DE", "fr").Language(), "earlier preferences should win")
+	assert.Equal(t, "fr", bundle.Localizer("ja", "fr").Language())
+}
+
+func TestLoadRejectsInvalidCatalogs(t *testing.T) {
+
+	dir := t.TempDir()
+	_, err := Load(dir, "en")
+	assert.Error(t, err, "the fallback catalog is required")
+
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"votes": {"one": "%d vote"}}`), 0644)
+	_, err = Load(dir, "en")
+	assert.Error(t, err, "plural forms need other")
+
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"a": "b"`), 0644)
+	_, err = Load(dir, "en")
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+// Package imagecache serves the images of themoviedb from netstar. images are
+// fetched once, resized if themoviedb has no matching size and kept on disk.
+package imagecache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// a directory of cached files limited to a total size. the least recently used
+// files are removed first, the order survives restarts through the modification times
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int64
+}
+
+type entry struct {
+	name string
+	size int64
+}
+
+// opens the cache in dir, creating it if needed, and picks up the files already in it
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir, maxBytes: maxBytes, lru: list.New(), entries: map[string]*list.Element{}}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) == ".tmp" {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().After(infos[j].ModTime()) })
+	for _, info := range infos {
+		c.entries[info.Name()] = c.lru.PushBack(&entry{info.Name(), info.Size()})
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c, c.evict()
+}
+
+// the cached data of key
+func (c *Cache) Get(key string) ([]byte, bool) {
+	name := fileName(key)
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		c.remove(name)
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(filepath.Join(c.dir, name), now, now)
+	return data, true
+}
+
+// saves data under key and removes the least recently used files if the cache got too big
+func (c *Cache) Put(key string, data []byte) error {
+	name := fileName(key)
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(c.dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.Value.(*entry).size
+		c.lru.Remove(e)
+	}
+	c.entries[name] = c.lru.PushFront(&entry{name, int64(len(data))})
+	c.size += int64(len(data))
+	return c.evict()
+}
+
+// the total size of the cached files
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// removes files from the back until the cache fits. c.mu must be held
+func (c *Cache) evict() error {
+	for c.size > c.maxBytes && c.lru.Len() > 0 {
+		e := c.lru.Back()
+		old := e.Value.(*entry)
+		if err := os.Remove(filepath.Join(c.dir, old.name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		c.lru.Remove(e)
+		delete(c.entries, old.name)
+		c.size -= old.size
+	}
+	return nil
+}
+
+func (c *Cache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.Value.(*entry).size
+		c.lru.Remove(e)
+		delete(c.entries, name)
+	}
+}
+
+// keys contain slashes, so files are named by their hash
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package imagecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachePutAndGet(t *testing.T) {
+
+	cache, err := Open(t.TempDir(), 100)
+	assert.Nil(t, err)
+
+	assert.Nil(t, cache.Put("w342/a.jpg", []byte("first")))
+	data, ok := cache.Get("w342/a.jpg")
+
+	assert.True(t, ok)
+	assert.Equal(t, "first", string(data))
+	_, ok = cache.Get("w342/b.jpg")
+	assert.False(t, ok)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+
+	dir := t.TempDir()
+	cache, _ := Open(dir, 10)
+
+	cache.Put("a", []byte("aaaa"))
+	cache.Put("b", []byte("bbbb"))
+	cache.Get("a")
+	cache.Put("c", []byte("cccc"))
+
+	_, ok := cache.Get("b")
+	assert.False(t, ok, "b was used least recently")
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), cache.Size())
+
+	files, _ := os.ReadDir(dir)
+	assert.Len(t, files, 2, "evicted files should be removed from disk")
+}
+
+func TestCacheReopens(t *testing.T) {
+
+	dir := t.TempDir()
+	cache, _ := Open(dir, 100)
+	cache.Put("a", []byte("aaaa"))
+	cache.Put("b", []byte("bbbb"))
+	os.WriteFile(filepath.Join(dir, "left.tmp"), []byte("x"), 0644)
+
+	reopened, err := Open(dir, 6)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), reopened.Size(), "the cache should shrink to its new limit")
+	data, ok := reopened.Get("b")
+	assert.True(t, ok, "the newest file should be kept")
+	assert.Equal(t, "bbbb", string(data))
+}
@@ -0,0 +1,155 @@
+package imagecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the widths images can be requested in, besides "original"
+var Widths = []int{45, 92, 154, 185, 300, 342, 500, 780, 1280}
+
+// the largest image that is fetched from themoviedb
+const maxImageSize = 20 << 20
+
+// file names of themoviedb images like 5tSHzkJ1HBnyGdcpr6wSyw7jYnJ.jpg
+var validFile = regexp.MustCompile(`^[A-Za-z0-9_-]+\.(jpg|jpeg|png|svg)$`)
+
+var errNotFound = errors.New("image not found")
+
+// shown instead of images that cannot be loaded
+const placeholder = `<svg xmlns="http://www.w3.org/2000/svg" width="342" height="513" viewBox="0 0 342 513">` +
+	`<rect width="342" height="513" fill="#dbdbdb"/>` +
+	`<path d="M131 216h80v60h-80z M145 230h52v32h-52z" fill="#b5b5b5" fill-rule="evenodd"/></svg>`
+
+// fetches images from the themoviedb image host, caches and serves them
+type Proxy struct {
+	Cache  *Cache
+	Client *http.Client
+	// like https://image.tmdb.org/t/p
+	BaseURL string
+}
+
+func NewProxy(cache *Cache, client *http.Client, baseURL string) *Proxy {
+	return &Proxy{Cache: cache, Client: client, BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// checks a size like "w342" or "original" against the widths netstar serves and returns the width, 0 for original
+func ParseSize(size string) (int, bool) {
+	if size == "original" {
+		return 0, true
+	}
+	width, err := strconv.Atoi(strings.TrimPrefix(size, "w"))
+	if err != nil || !strings.HasPrefix(size, "w") {
+		return 0, false
+	}
+	for _, w := range Widths {
+		if w == width {
+			return width, true
+		}
+	}
+	return 0, false
+}
+
+// keeps scripts in svg files from running when an image is opened directly,
+// they come from themoviedb but are served from the origin of netstar
+const imagePolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+// serves the image file at size. images never change on themoviedb, so browsers
+// may keep them for a year
+func (p *Proxy) Serve(w http.ResponseWriter, r *http.Request, size, file string) {
+	w.Header().Set("Content-Security-Policy", imagePolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	width, ok := ParseSize(size)
+	if !ok || !validFile.MatchString(file) {
+		servePlaceholder(w, http.StatusNotFound)
+		return
+	}
+
+	data, err := p.image(size, width, file)
+	if err != nil {
+		log.Printf("Could not load image %s/%s: %v", size, file, err)
+		status := http.StatusBadGateway
+		if err == errNotFound {
+			status = http.StatusNotFound
+		}
+		servePlaceholder(w, status)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("Content-Type", contentType(file, data))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// the image from the cache, else from themoviedb. sizes themoviedb does not have are made from the original
+func (p *Proxy) image(size string, width int, file string) ([]byte, error) {
+	key := size + "/" + file
+	if data, ok := p.Cache.Get(key); ok {
+		return data, nil
+	}
+
+	data, err := p.fetch(size, file)
+	if err == errNotFound && width > 0 {
+		if data, err = p.image("original", 0, file); err == nil && path.Ext(file) != ".svg" {
+			data, err = resize(data, width)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Cache.Put(key, data); err != nil {
+		log.Printf("Could not cache image %s: %v", key, err)
+	}
+	return data, nil
+}
+
+func (p *Proxy) fetch(size, file string) ([]byte, error) {
+	resp, err := p.Client.Get(p.BaseURL + "/" + size + "/" + file)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("image host answered with %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err == nil && len(data) > maxImageSize {
+		err = errors.New("image is too large")
+	}
+	return data, err
+}
+
+func contentType(file string, data []byte) string {
+	if t := mime.TypeByExtension(path.Ext(file)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// the placeholder is not cached, the image may be there next time
+func servePlaceholder(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	io.WriteString(w, placeholder)
+}
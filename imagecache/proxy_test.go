@@ -0,0 +1,132 @@
+package imagecache
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GetTestProxy(t *testing.T) (*Proxy, *int) {
+	original := testImage(600, 900, func(buf *bytes.Buffer, img image.Image) { jpeg.Encode(buf, img, nil) })
+	requests := 0
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/w342/poster.jpg":
+			w.Write([]byte("w342 poster"))
+		case "/original/poster.jpg":
+			w.Write(original)
+		case "/original/logo.svg":
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+		case "/w92/broken.jpg":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(imageServer.Close)
+
+	cache, err := Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProxy(cache, imageServer.Client(), imageServer.URL+"/"), &requests
+}
+
+func ServeImage(proxy *Proxy, size, file string, header http.Header) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("GET", "/img/"+size+"/"+file, nil)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	proxy.Serve(recorder, request, size, file)
+	return recorder
+}
+
+func TestProxyCachesImages(t *testing.T) {
+
+	proxy, requests := GetTestProxy(t)
+
+	first := ServeImage(proxy, "w342", "poster.jpg", nil)
+	second := ServeImage(proxy, "w342", "poster.jpg", nil)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "w342 poster", second.Body.String())
+	assert.Equal(t, 1, *requests, "the second request should be served from the cache")
+	assert.Equal(t, "image/jpeg", first.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=31536000, immutable", first.Header().Get("Cache-Control"))
+
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	notModified := ServeImage(proxy, "w342", "poster.jpg", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+}
+
+func TestProxySandboxesImages(t *testing.T) {
+
+	proxy, _ := GetTestProxy(t)
+
+	for _, file := range []string{"logo.svg", "missing.jpg"} {
+		recorder := ServeImage(proxy, "original", file, nil)
+		assert.Equal(t, imagePolicy, recorder.Header().Get("Content-Security-Policy"), file)
+		assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"), file)
+	}
+}
+
+func TestProxyResizesMissingSizes(t *testing.T) {
+
+	proxy, requests := GetTestProxy(t)
+
+	recorder := ServeImage(proxy, "w185", "poster.jpg", nil)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	img, _, err := image.Decode(recorder.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, 185, img.Bounds().Dx())
+	assert.Equal(t, 2, *requests, "the original should be fetched once w185 was not found")
+
+	ServeImage(proxy, "w185", "poster.jpg", nil)
+	ServeImage(proxy, "original", "poster.jpg", nil)
+	assert.Equal(t, 2, *requests, "resized and original images should be cached")
+}
+
+func TestProxyServesPlaceholder(t *testing.T) {
+
+	proxy, requests := GetTestProxy(t)
+
+	for _, c := range []struct {
+		size, file string
+		status     int
+	}{
+		{"w342", "missing.jpg", http.StatusNotFound},
+		{"w92", "broken.jpg", http.StatusBadGateway},
+		{"w100", "poster.jpg", http.StatusNotFound},
+		{"w342", "..%2fsecret.jpg", http.StatusNotFound},
+	} {
+		recorder := ServeImage(proxy, c.size, c.file, nil)
+		assert.Equal(t, c.status, recorder.Code, c.file)
+		assert.Equal(t, "image/svg+xml", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	}
+	assert.Equal(t, 3, *requests, "invalid sizes and files should not be fetched")
+}
+
+func TestParseSize(t *testing.T) {
+
+	width, ok := ParseSize("w500")
+	assert.True(t, ok)
+	assert.Equal(t, 500, width)
+
+	_, ok = ParseSize("original")
+	assert.True(t, ok)
+
+	for _, size := range []string{"w501", "500", "h632", "wide"} {
+		_, ok = ParseSize(size)
+		assert.False(t, ok, size)
+	}
+}
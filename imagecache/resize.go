@@ -0,0 +1,55 @@
+package imagecache
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// scales the jpeg or png in data down to width, averaging the pixels each new pixel covers.
+// images that are already small enough are returned as they are
+func resize(data []byte, width int) ([]byte, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	if bounds.Dx() <= width {
+		return data, nil
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*bounds.Dy()/height, (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0, x1 := x*bounds.Dx()/width, (x+1)*bounds.Dx()/width
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, b, a, n = r+int(p[0]), g+int(p[1]), b+int(p[2]), a+int(p[3]), n+1
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if format == "png" {
+		err = png.Encode(out, dst)
+	} else {
+		err = jpeg.Encode(out, dst, &jpeg.Options{Quality: 85})
+	}
+	return out.Bytes(), err
+}
@@ -0,0 +1,54 @@
+package imagecache
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(width, height int, encode func(*bytes.Buffer, image.Image)) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 200, 255})
+		}
+	}
+	buf := &bytes.Buffer{}
+	encode(buf, img)
+	return buf.Bytes()
+}
+
+func TestResize(t *testing.T) {
+
+	data := testImage(200, 300, func(buf *bytes.Buffer, img image.Image) { jpeg.Encode(buf, img, nil) })
+
+	resized, err := resize(data, 92)
+
+	assert.Nil(t, err)
+	img, format, err := image.Decode(bytes.NewReader(resized))
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, image.Rect(0, 0, 92, 138), img.Bounds(), "the aspect ratio should be kept")
+}
+
+func TestResizeKeepsPNGAndSmallImages(t *testing.T) {
+
+	data := testImage(100, 50, func(buf *bytes.Buffer, img image.Image) { png.Encode(buf, img) })
+
+	resized, err := resize(data, 45)
+	assert.Nil(t, err)
+	_, format, _ := image.Decode(bytes.NewReader(resized))
+	assert.Equal(t, "png", format, "logos should keep their transparency")
+
+	same, err := resize(data, 300)
+	assert.Nil(t, err)
+	assert.Equal(t, data, same, "images should not be scaled up")
+
+	_, err = resize([]byte("no image"), 92)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+
+	"bereths.com/netstar/imagecache"
+	"bereths.com/netstar/themoviedb"
+	"github.com/gorilla/mux"
+)
+
+// the images of themoviedb are served by netstar below this path, see ImageHandler
+const imagePath = "/img"
+
+// the url of the themoviedb image path at size on netstar, like /img/w500/abc.jpg
+func imageURL(size, path string) string {
+	if path == "" {
+		return ""
+	}
+	return imagePath + "/" + size + path
+}
+
+func (p DetailsPage) PosterSrcSet() string {
+	return themoviedb.SrcSet(imagePath, themoviedb.PosterImage, p.PosterPath)
+}
+
+func (p DetailsPage) BackdropSrcSet() string {
+	return themoviedb.SrcSet(imagePath, themoviedb.BackdropImage, p.BackdropPath)
+}
+
+func (p SeasonPage) PosterSrcSet() string {
+	return themoviedb.SrcSet(imagePath, themoviedb.PosterImage, p.PosterPath)
+}
+
+func (p EpisodePage) StillSrcSet() string {
+	return themoviedb.SrcSet(imagePath, themoviedb.StillImage, p.StillPath)
+}
+
+// serves images like /img/w342/abc.jpg from the image cache. without a cache the browser
+// is sent to themoviedb
+func ImageHandler(proxy *imagecache.Proxy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if proxy == nil {
+			http.Redirect(w, r, themoviedb.ImageURL(vars["size"], "/"+vars["file"]), http.StatusFound)
+			return
+		}
+		proxy.Serve(w, r, vars["size"], vars["file"])
+	}
+}
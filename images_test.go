@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bereths.com/netstar/imagecache"
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageHandlerServesFromCache(t *testing.T) {
+
+	requests := 0
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/w342/poster.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("poster"))
+	}))
+	defer imageServer.Close()
+
+	cache, err := imagecache.Open(t.TempDir(), 1<<20)
+	assert.Nil(t, err)
+	mockServer := httptest.NewServer(NewAppRouter(&App{
+		TMDB:   GetValidClient(),
+		Images: imagecache.NewProxy(cache, imageServer.Client(), imageServer.URL),
+	}))
+	defer mockServer.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, _ := http.Get(mockServer.URL + "/img/w342/poster.jpg")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "poster", ReadBody(t, resp))
+		assert.NotEmpty(t, resp.Header.Get("ETag"))
+	}
+	assert.Equal(t, 1, requests)
+
+	resp, _ := http.Get(mockServer.URL + "/img/w342/missing.jpg")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "<svg")
+}
+
+func TestImageHandlerRedirectsWithoutCache(t *testing.T) {
+
+	mockServer := httptest.NewServer(NewRouter(GetValidClient()))
+	defer mockServer.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, _ := client.Get(mockServer.URL + "/img/w500/poster.jpg")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://image.tmdb.org/t/p/w500/poster.jpg", resp.Header.Get("Location"))
+}
+
+func TestPageSrcSets(t *testing.T) {
+
+	page := DetailsPage{TVShowDetails: &themoviedb.TVShowDetails{PosterPath: "/p.jpg", BackdropPath: "/b.jpg"}}
+	assert.Contains(t, page.PosterSrcSet(), "/img/w92/p.jpg 92w")
+	assert.Contains(t, page.BackdropSrcSet(), "/img/w1280/b.jpg 1280w")
+	assert.Equal(t, "", imageURL("w500", ""))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"bereths.com/netstar/backup"
+	"bereths.com/netstar/importer"
+	"bereths.com/netstar/users"
+)
+
+// the largest export that can be uploaded
+const maxImportSize = 32 << 20
+
+var (
+	errNoExport      = errors.New("choose an export to upload")
+	errUnknownExport = errors.New("only trakt backups (.zip, .json) and imdb exports (.csv) can be imported")
+)
+
+// the upload form and, after an upload, what was imported
+type ImportPage struct {
+	Report *importer.Report
+	// what was restored from a netstar export
+	Restored *backup.Report
+	// a message id or the text of an error without a message
+	Error string
+}
+
+// shows the form to upload an export
+func ImportPageHandler(w http.ResponseWriter, r *http.Request) {
+	if users.CurrentUser(r) == nil {
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		return
+	}
+	render(w, r, importPage, &ImportPage{})
+}
+
+// imports an uploaded trakt backup (.zip or a single .json) or imdb export (.csv)
+func ImportHandler(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		records, unmatched, err := readExport(w, r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render(w, r, importPage, &ImportPage{Error: errorMessage(err)})
+			return
+		}
+
+		report, err := importer.New(app.TMDB, app.Watchlist, app.Progress, app.Ratings, user.ID, user.Username).Import(records)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		report.Unmatched = append(unmatched, report.Unmatched...)
+
+		render(w, r, importPage, &ImportPage{Report: report})
+	}
+}
+
+// reads the records of the uploaded file, the kind of export is told by its extension
+func readExport(w http.ResponseWriter, r *http.Request) ([]importer.Record, []importer.Unmatched, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, nil, errNoExport
+	}
+	defer file.Close()
+
+	name := header.Filename
+	switch strings.ToLower(path.Ext(name)) {
+	case ".zip":
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		return importer.ReadTraktZip(bytes.NewReader(content), int64(len(content)))
+	case ".json":
+		return importer.ReadTrakt(name, file)
+	case ".csv":
+		return importer.ReadIMDb(name, file)
+	}
+	return nil, nil, errUnknownExport
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bereths.com/netstar/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+func PostImport(t *testing.T, client *http.Client, url, token, filename, content string) *http.Response {
+	buf := &bytes.Buffer{}
+	form := multipart.NewWriter(buf)
+	form.WriteField("csrf_token", token)
+	if filename != "" {
+		part, _ := form.CreateFormFile("file", filename)
+		part.Write([]byte(content))
+	}
+	form.Close()
+
+	resp, err := client.Post(url, form.FormDataContentType(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestImportIMDbRatings(t *testing.T) {
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+		case "/find/tt6305578":
+			w.Write([]byte(`{"tv_episode_results":[{"id":1,"name":"Secrets","show_id":70523,"season_number":1,"episode_number":1}]}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer tmdbServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/import")
+
+	export := "Const,Your Rating,Date Rated,Title,URL,Title Type\n" +
+		"tt6305578,9,2020-07-01,Secrets,https://www.imdb.com/title/tt6305578/,tvEpisode\n" +
+		"tt0000001,7,2020-07-01,Nothing,https://www.imdb.com/title/tt0000001/,tvSeries\n"
+
+	resp := PostImport(t, client, mockServer.URL+"/import", token, "ratings.csv", export)
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "1 watched episodes and 1 ratings")
+	assert.Contains(t, body, "ratings.csv line 3")
+	assert.Contains(t, body, "imdb_id tt0000001 is not a known tv show")
+
+	watched, _ := app.Progress.Watched(1, 70523)
+	assert.Equal(t, progress.Watched{{Season: 1, Number: 1}: true}, watched)
+}
+
+func TestImportWithInvalidFile(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/import")
+
+	resp := PostImport(t, client, mockServer.URL+"/import", token, "history.txt", "Dark")
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, body, "only trakt backups")
+
+	resp = PostImport(t, client, mockServer.URL+"/import", token, "", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestImportPageRequiresLogin(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+
+	resp, _ := client.Get(mockServer.URL + "/import")
+	resp.Body.Close()
+
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/ratings"
+)
+
+// reads a ratings or watchlist csv export of imdb. ratings have a "Your Rating" column,
+// rated episodes are imported as watched as well
+func ReadIMDb(name string, r io.Reader) ([]Record, []Unmatched, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not an imdb export: %w", name, err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+	for _, column := range []string{"Const", "Title", "Title Type"} {
+		if _, ok := columns[column]; !ok {
+			return nil, nil, fmt.Errorf("%s is not an imdb export: no %q column", name, column)
+		}
+	}
+	_, isRatings := columns["Your Rating"]
+
+	var records []Record
+	var unmatched []Unmatched
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		record := Record{Source: fmt.Sprintf("%s line %d", name, line), Title: value("Title")}
+		switch value("Title Type") {
+		case "tvSeries", "tvMiniSeries", "TV Series", "TV Mini Series":
+			record.Level, record.Show.IMDb = ratings.Show, value("Const")
+		case "tvEpisode", "TV Episode":
+			record.Level, record.EpisodeIDs.IMDb = ratings.Episode, value("Const")
+		default:
+			unmatched = append(unmatched, Unmatched{record.Source, record.Title, "only tv shows can be imported"})
+			continue
+		}
+
+		if !isRatings {
+			if record.Level != ratings.Show {
+				unmatched = append(unmatched, Unmatched{record.Source, record.Title, "only shows can be on the watchlist"})
+				continue
+			}
+			record.Kind = Watchlist
+			records = append(records, record)
+			continue
+		}
+
+		rating, err := strconv.Atoi(value("Your Rating"))
+		if err != nil {
+			unmatched = append(unmatched, Unmatched{record.Source, record.Title, "invalid rating"})
+			continue
+		}
+		record.Kind, record.Rating = Rating, rating
+		records = append(records, record)
+
+		if record.Level == ratings.Episode {
+			watched := record
+			watched.Kind, watched.Rating = Watched, 0
+			records = append(records, watched)
+		}
+	}
+	return records, unmatched, nil
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"bereths.com/netstar/ratings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIMDbRatings(t *testing.T) {
+
+	export := "\ufeffConst,Your Rating,Date Rated,Title,URL,Title Type,IMDb Rating\n" +
+		"tt5753856,10,2020-07-01,Dark,https://www.imdb.com/title/tt5753856/,tvSeries,8.7\n" +
+		"tt6305578,9,2020-07-01,Secrets,https://www.imdb.com/title/tt6305578/,tvEpisode,8.2\n" +
+		"tt0390384,8,2020-07-01,Primer,https://www.imdb.com/title/tt0390384/,movie,6.9\n" +
+		"tt0000001,,2020-07-01,Nothing,https://www.imdb.com/title/tt0000001/,tvSeries,5\n"
+
+	records, unmatched, err := ReadIMDb("ratings.csv", strings.NewReader(export))
+
+	assert.Nil(t, err)
+	if assert.Len(t, records, 3, "rated episodes should be watched as well") {
+		assert.Equal(t, Record{Source: "ratings.csv line 2", Kind: Rating, Title: "Dark", Level: ratings.Show, Show: IDs{IMDb: "tt5753856"}, Rating: 10}, records[0])
+		assert.Equal(t, "tt6305578", records[1].EpisodeIDs.IMDb)
+		assert.Equal(t, Watched, records[2].Kind)
+	}
+	if assert.Len(t, unmatched, 2) {
+		assert.Equal(t, "only tv shows can be imported", unmatched[0].Reason)
+		assert.Equal(t, "invalid rating", unmatched[1].Reason)
+	}
+}
+
+func TestReadIMDbWatchlist(t *testing.T) {
+
+	export := "Position,Const,Created,Modified,Description,Title,URL,Title Type\n" +
+		"1,tt5753856,2020-07-01,2020-07-01,,Dark,https://www.imdb.com/title/tt5753856/,tvSeries\n"
+
+	records, _, err := ReadIMDb("watchlist.csv", strings.NewReader(export))
+
+	assert.Nil(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, Watchlist, records[0].Kind)
+	}
+}
+
+func TestReadIMDbWithInvalidFile(t *testing.T) {
+
+	_, _, err := ReadIMDb("list.csv", strings.NewReader("name,year\nDark,2017\n"))
+
+	assert.NotNil(t, err)
+}
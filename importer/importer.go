@@ -0,0 +1,256 @@
+// Package importer brings the watch history, watchlist and ratings users keep on
+// other sites like Trakt or IMDb into netstar.
+package importer
+
+import (
+	"fmt"
+	"strconv"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/watchlist"
+)
+
+// what a record adds
+const (
+	Watchlist = "watchlist"
+	Watched   = "watched"
+	Rating    = "rating"
+)
+
+// ids of a show or episode in the databases the exports use
+type IDs struct {
+	TMDB int
+	IMDb string
+	TVDB int
+}
+
+// a row of an export
+type Record struct {
+	// where the record comes from, like "ratings.csv line 4"
+	Source string
+	Kind   string
+	Title  string
+	// the show, season or episode the record is about, see ratings.Show and friends
+	Level   string
+	Show    IDs
+	Season  int
+	Episode int
+	// ids of the episode itself, imdb exports only know these
+	EpisodeIDs IDs
+	Rating     int
+}
+
+// a record that could not be imported and why
+type Unmatched struct {
+	Source string `json:"source"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// what was imported
+type Report struct {
+	Watchlist int         `json:"watchlist"`
+	Watched   int         `json:"watched"`
+	Ratings   int         `json:"ratings"`
+	Unmatched []Unmatched `json:"unmatched"`
+}
+
+// adds records to the data of a user
+type Importer struct {
+	TMDB      *themoviedb.Client
+	Watchlist *watchlist.Store
+	Progress  *progress.Store
+	Ratings   *ratings.Store
+
+	UserID   int
+	Username string
+
+	shows map[int]*themoviedb.TVShowDetails
+	found map[string]*themoviedb.FindResults
+}
+
+func New(client *themoviedb.Client, wl *watchlist.Store, pr *progress.Store, rs *ratings.Store, userID int, username string) *Importer {
+	return &Importer{
+		TMDB:      client,
+		Watchlist: wl,
+		Progress:  pr,
+		Ratings:   rs,
+		UserID:    userID,
+		Username:  username,
+		shows:     map[int]*themoviedb.TVShowDetails{},
+		found:     map[string]*themoviedb.FindResults{},
+	}
+}
+
+// matches the records with themoviedb and saves them. watched episodes are saved
+// per show at the end, exports list thousands of them
+func (im *Importer) Import(records []Record) (*Report, error) {
+	report := &Report{Unmatched: []Unmatched{}}
+	watched := map[int]map[progress.Episode]bool{}
+
+	for _, record := range records {
+		show, season, episode, err := im.match(record)
+		if err != nil {
+			report.Unmatched = append(report.Unmatched, Unmatched{record.Source, record.Title, err.Error()})
+			continue
+		}
+
+		switch record.Kind {
+		case Watchlist:
+			entry := watchlist.Entry{ShowID: show.ID, Name: show.Name, PosterPath: show.PosterPath}
+			if err := im.Watchlist.Add(im.UserID, entry); err != nil {
+				return nil, err
+			}
+			report.Watchlist++
+
+		case Watched:
+			if watched[show.ID] == nil {
+				watched[show.ID] = map[progress.Episode]bool{}
+			}
+			watched[show.ID][progress.Episode{Season: season, Number: episode}] = true
+
+		case Rating:
+			target := ratings.Target{Kind: record.Level, ShowID: show.ID, Season: season, Episode: episode}
+			current, err := im.Ratings.For(target)
+			if err != nil {
+				return nil, err
+			}
+			review := ratings.Review{
+				Target:   target,
+				UserID:   im.UserID,
+				Username: im.Username,
+				Name:     name(show, record, season, episode),
+				Rating:   record.Rating,
+			}
+			// a newer export may change the rating, the text written here is kept
+			if existing := reviewBy(current, im.UserID); existing != nil {
+				if existing.Rating == record.Rating {
+					continue
+				}
+				review.Text = existing.Text
+			}
+			if err := im.Ratings.Put(review); err != nil {
+				report.Unmatched = append(report.Unmatched, Unmatched{record.Source, record.Title, err.Error()})
+				continue
+			}
+			report.Ratings++
+		}
+	}
+
+	for showID, episodes := range watched {
+		list := make([]progress.Episode, 0, len(episodes))
+		for ep := range episodes {
+			list = append(list, ep)
+		}
+		if err := im.Progress.Mark(im.UserID, showID, list, true); err != nil {
+			return nil, err
+		}
+		report.Watched += len(list)
+	}
+	return report, nil
+}
+
+// the show of the record and the season and episode on themoviedb
+func (im *Importer) match(record Record) (*themoviedb.TVShowDetails, int, int, error) {
+	season, episode := record.Season, record.Episode
+
+	showID := record.Show.TMDB
+	switch {
+	case record.Level == ratings.Episode && record.EpisodeIDs.IMDb != "":
+		found, err := im.find(record.EpisodeIDs.IMDb, themoviedb.IMDbID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if len(found.TVEpisodeResults) == 0 {
+			return nil, 0, 0, fmt.Errorf("imdb id %s is not a known episode", record.EpisodeIDs.IMDb)
+		}
+		ep := found.TVEpisodeResults[0]
+		showID, season, episode = ep.ShowID, ep.SeasonNumber, ep.EpisodeNumber
+
+	case showID == 0 && record.Show.IMDb != "":
+		id, err := im.findShow(record.Show.IMDb, themoviedb.IMDbID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		showID = id
+
+	case showID == 0 && record.Show.TVDB != 0:
+		id, err := im.findShow(strconv.Itoa(record.Show.TVDB), themoviedb.TVDBID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		showID = id
+	}
+
+	if showID == 0 {
+		return nil, 0, 0, fmt.Errorf("no id themoviedb knows")
+	}
+
+	show, err := im.show(showID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return show, season, episode, nil
+}
+
+func (im *Importer) findShow(id, source string) (int, error) {
+	found, err := im.find(id, source)
+	if err != nil {
+		return 0, err
+	}
+	if len(found.TVResults) == 0 {
+		return 0, fmt.Errorf("%s %s is not a known tv show", source, id)
+	}
+	return found.TVResults[0].ID, nil
+}
+
+func (im *Importer) find(id, source string) (*themoviedb.FindResults, error) {
+	key := source + "/" + id
+	if found, ok := im.found[key]; ok {
+		return found, nil
+	}
+	found, err := im.TMDB.Find(id, source)
+	if err != nil {
+		return nil, err
+	}
+	im.found[key] = found
+	return found, nil
+}
+
+func (im *Importer) show(id int) (*themoviedb.TVShowDetails, error) {
+	if show, ok := im.shows[id]; ok {
+		return show, nil
+	}
+	show, err := im.TMDB.GetTVShowDetails(strconv.Itoa(id))
+	if err != nil {
+		if themoviedb.IsNotFound(err) {
+			return nil, fmt.Errorf("show %d is not on themoviedb", id)
+		}
+		return nil, err
+	}
+	im.shows[id] = show
+	return show, nil
+}
+
+// the name of the rated target like reviews written on netstar have
+func name(show *themoviedb.TVShowDetails, record Record, season, episode int) string {
+	switch record.Level {
+	case ratings.Season:
+		return fmt.Sprintf("%s Season %d", show.Name, season)
+	case ratings.Episode:
+		return fmt.Sprintf("%s S%02dE%02d %s", show.Name, season, episode, record.Title)
+	}
+	return show.Name
+}
+
+// the review of the user, nil if there is none
+func reviewBy(reviews []ratings.Review, userID int) *ratings.Review {
+	for i := range reviews {
+		if reviews[i].UserID == userID {
+			return &reviews[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestImporter(t *testing.T) *Importer {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","poster_path":"/dark.jpg"}`))
+		case "/find/tt5753856":
+			w.Write([]byte(`{"tv_results":[{"id":70523,"name":"Dark"}],"tv_episode_results":[]}`))
+		case "/find/tt6305578":
+			w.Write([]byte(`{"tv_results":[],"tv_episode_results":[{"id":1,"name":"Secrets","show_id":70523,"season_number":1,"episode_number":1}]}`))
+		case "/find/334824":
+			assert.Equal(t, themoviedb.TVDBID, r.URL.Query().Get("external_source"))
+			w.Write([]byte(`{"tv_results":[{"id":70523,"name":"Dark"}]}`))
+		case "/find/tt0000001":
+			w.Write([]byte(`{"tv_results":[],"tv_episode_results":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+	client := themoviedb.NewClient(&http.Client{Timeout: time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(mockServer.URL)
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wl, _ := watchlist.NewStore(db)
+	pr, _ := progress.NewStore(db)
+	rs, _ := ratings.NewStore(db)
+
+	return New(client, wl, pr, rs, 1, "jonas")
+}
+
+func TestImport(t *testing.T) {
+
+	im := GetTestImporter(t)
+
+	report, err := im.Import([]Record{
+		{Source: "a", Kind: Watchlist, Level: ratings.Show, Show: IDs{IMDb: "tt5753856"}},
+		{Source: "b", Kind: Watched, Level: ratings.Episode, Show: IDs{TVDB: 334824}, Season: 1, Episode: 2},
+		{Source: "c", Kind: Watched, Level: ratings.Episode, EpisodeIDs: IDs{IMDb: "tt6305578"}},
+		{Source: "d", Kind: Watched, Level: ratings.Episode, EpisodeIDs: IDs{IMDb: "tt6305578"}},
+		{Source: "e", Kind: Rating, Level: ratings.Episode, Title: "Secrets", EpisodeIDs: IDs{IMDb: "tt6305578"}, Rating: 9},
+		{Source: "f", Kind: Rating, Level: ratings.Show, Title: "Unknown", Show: IDs{IMDb: "tt0000001"}, Rating: 5},
+		{Source: "g", Kind: Rating, Level: ratings.Show, Title: "Gone", Show: IDs{TMDB: 1}, Rating: 5},
+		{Source: "h", Kind: Rating, Level: ratings.Show, Title: "Nothing"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Watchlist)
+	assert.Equal(t, 2, report.Watched, "duplicate episodes should count once")
+	assert.Equal(t, 1, report.Ratings)
+	if assert.Len(t, report.Unmatched, 3) {
+		assert.Equal(t, Unmatched{"f", "Unknown", "imdb_id tt0000001 is not a known tv show"}, report.Unmatched[0])
+		assert.Equal(t, "show 1 is not on themoviedb", report.Unmatched[1].Reason)
+		assert.Equal(t, "no id themoviedb knows", report.Unmatched[2].Reason)
+	}
+
+	assert.True(t, im.Watchlist.Has(1, 70523))
+	watched, _ := im.Progress.Watched(1, 70523)
+	assert.Equal(t, progress.Watched{{Season: 1, Number: 1}: true, {Season: 1, Number: 2}: true}, watched)
+	reviews, _ := im.Ratings.For(ratings.Target{Kind: ratings.Episode, ShowID: 70523, Season: 1, Episode: 1})
+	if assert.Len(t, reviews, 1) {
+		assert.Equal(t, "Dark S01E01 Secrets", reviews[0].Name)
+	}
+}
+
+func TestImportKeepsExistingReviews(t *testing.T) {
+
+	im := GetTestImporter(t)
+	target := ratings.Target{Kind: ratings.Episode, ShowID: 70523, Season: 1, Episode: 1}
+	im.Ratings.Put(ratings.Review{Target: target, UserID: 1, Username: "jonas", Rating: 8, Text: "Everything is connected"})
+
+	records := []Record{{Source: "a", Kind: Rating, Level: ratings.Episode, Title: "Secrets", EpisodeIDs: IDs{IMDb: "tt6305578"}, Rating: 8}}
+	report, err := im.Import(records)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.Ratings, "unchanged ratings should be skipped")
+
+	reviews, _ := im.Ratings.For(target)
+	if assert.Len(t, reviews, 1) {
+		assert.Equal(t, 8, reviews[0].Rating)
+		assert.Equal(t, "Everything is connected", reviews[0].Text)
+	}
+}
+
+func TestImportUpdatesChangedRatings(t *testing.T) {
+
+	im := GetTestImporter(t)
+	target := ratings.Target{Kind: ratings.Episode, ShowID: 70523, Season: 1, Episode: 1}
+	im.Ratings.Put(ratings.Review{Target: target, UserID: 1, Username: "jonas", Rating: 8, Text: "Everything is connected"})
+
+	records := []Record{{Source: "a", Kind: Rating, Level: ratings.Episode, Title: "Secrets", EpisodeIDs: IDs{IMDb: "tt6305578"}, Rating: 9}}
+	for i, changed := range []int{1, 0} {
+		report, err := im.Import(records)
+		assert.Nil(t, err)
+		assert.Equal(t, changed, report.Ratings, "import %d", i+1)
+	}
+
+	reviews, _ := im.Ratings.For(target)
+	if assert.Len(t, reviews, 1) {
+		assert.Equal(t, 9, reviews[0].Rating, "the rating of the newer export should win")
+		assert.Equal(t, "Everything is connected", reviews[0].Text, "the text should be kept")
+	}
+}
@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"bereths.com/netstar/ratings"
+)
+
+type traktIDs struct {
+	TMDB int    `json:"tmdb"`
+	IMDb string `json:"imdb"`
+	TVDB int    `json:"tvdb"`
+}
+
+type traktShow struct {
+	Title string   `json:"title"`
+	Year  int      `json:"year"`
+	IDs   traktIDs `json:"ids"`
+}
+
+// an entry of the json files in a trakt backup. watched-shows.json lists shows with
+// their seasons, history.json single episodes, watchlist and ratings files both
+type traktItem struct {
+	Type      string     `json:"type"`
+	Rating    int        `json:"rating"`
+	ListedAt  string     `json:"listed_at"`
+	WatchedAt string     `json:"watched_at"`
+	Show      *traktShow `json:"show"`
+	Season    *struct {
+		Number int `json:"number"`
+	} `json:"season"`
+	Episode *struct {
+		Season int    `json:"season"`
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	} `json:"episode"`
+	Seasons []struct {
+		Number   int `json:"number"`
+		Episodes []struct {
+			Number int `json:"number"`
+		} `json:"episodes"`
+	} `json:"seasons"`
+}
+
+// reads a json file of a trakt backup like watched-shows.json or ratings-episodes.json
+func ReadTrakt(name string, r io.Reader) ([]Record, []Unmatched, error) {
+	var items []traktItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("%s is not a trakt export: %w", name, err)
+	}
+
+	var records []Record
+	var unmatched []Unmatched
+	for i, item := range items {
+		source := fmt.Sprintf("%s #%d", name, i+1)
+		if item.Show == nil {
+			unmatched = append(unmatched, Unmatched{source, "", "only tv shows can be imported"})
+			continue
+		}
+
+		record := Record{
+			Source: source,
+			Title:  item.Show.Title,
+			Level:  ratings.Show,
+			Show:   IDs{item.Show.IDs.TMDB, item.Show.IDs.IMDb, item.Show.IDs.TVDB},
+		}
+		if item.Season != nil {
+			record.Level, record.Season = ratings.Season, item.Season.Number
+		}
+		if item.Episode != nil {
+			record.Level, record.Season, record.Episode = ratings.Episode, item.Episode.Season, item.Episode.Number
+			record.Title = item.Episode.Title
+		}
+
+		switch {
+		case item.Rating > 0:
+			record.Kind, record.Rating = Rating, item.Rating
+			records = append(records, record)
+
+		case item.ListedAt != "":
+			record.Kind, record.Level = Watchlist, ratings.Show
+			records = append(records, record)
+
+		case item.WatchedAt != "" && item.Episode != nil:
+			record.Kind = Watched
+			records = append(records, record)
+
+		case len(item.Seasons) > 0:
+			for _, season := range item.Seasons {
+				for _, ep := range season.Episodes {
+					watched := record
+					watched.Kind, watched.Level, watched.Season, watched.Episode = Watched, ratings.Episode, season.Number, ep.Number
+					records = append(records, watched)
+				}
+			}
+
+		default:
+			unmatched = append(unmatched, Unmatched{source, record.Title, "neither watched, rated nor on the watchlist"})
+		}
+	}
+	return records, unmatched, nil
+}
+
+// reads the json files of a zipped trakt backup. files that are no lists of
+// shows, like the user profile, are skipped
+func ReadTraktZip(r io.ReaderAt, size int64) ([]Record, []Unmatched, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	var unmatched []Unmatched
+	for _, f := range archive.File {
+		name := path.Base(f.Name)
+		if !strings.HasSuffix(name, ".json") || !traktFile(name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		found, skipped, err := ReadTrakt(name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, found...)
+		unmatched = append(unmatched, skipped...)
+	}
+	return records, unmatched, nil
+}
+
+// the files of a backup with shows in them
+func traktFile(name string) bool {
+	for _, prefix := range []string{"watched-shows", "watchlist", "ratings-", "history"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
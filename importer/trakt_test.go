@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"bereths.com/netstar/ratings"
+	"github.com/stretchr/testify/assert"
+)
+
+const traktWatched = `[{"plays":3,"show":{"title":"Dark","year":2017,"ids":{"trakt":1,"tvdb":334824,"imdb":"tt5753856","tmdb":70523}},
+	"seasons":[{"number":1,"episodes":[{"number":1,"plays":1},{"number":2,"plays":1}]},{"number":2,"episodes":[{"number":1,"plays":1}]}]}]`
+
+const traktRatings = `[
+	{"rated_at":"2020-07-01T00:00:00.000Z","rating":10,"type":"show","show":{"title":"Dark","ids":{"tmdb":70523}}},
+	{"rated_at":"2020-07-01T00:00:00.000Z","rating":8,"type":"episode","show":{"title":"Dark","ids":{"tmdb":70523}},"episode":{"season":1,"number":3,"title":"Past and Present"}},
+	{"rated_at":"2020-07-01T00:00:00.000Z","rating":9,"type":"movie","movie":{"title":"Primer"}}]`
+
+func TestReadTraktWatched(t *testing.T) {
+
+	records, unmatched, err := ReadTrakt("watched-shows.json", strings.NewReader(traktWatched))
+
+	assert.Nil(t, err)
+	assert.Empty(t, unmatched)
+	if assert.Len(t, records, 3, "every watched episode should be a record") {
+		assert.Equal(t, Record{Source: "watched-shows.json #1", Kind: Watched, Title: "Dark", Level: ratings.Episode, Show: IDs{70523, "tt5753856", 334824}, Season: 2, Episode: 1}, records[2])
+	}
+}
+
+func TestReadTraktRatings(t *testing.T) {
+
+	records, unmatched, err := ReadTrakt("ratings.json", strings.NewReader(traktRatings))
+
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, ratings.Show, records[0].Level)
+	assert.Equal(t, 10, records[0].Rating)
+	assert.Equal(t, ratings.Episode, records[1].Level)
+	assert.Equal(t, "Past and Present", records[1].Title)
+	if assert.Len(t, unmatched, 1) {
+		assert.Equal(t, "ratings.json #3", unmatched[0].Source)
+	}
+}
+
+func TestReadTraktWithInvalidFile(t *testing.T) {
+
+	_, _, err := ReadTrakt("user.json", strings.NewReader(`{"username":"jonas"}`))
+
+	assert.NotNil(t, err)
+}
+
+func TestReadTraktZip(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"backup/watched-shows.json": traktWatched,
+		"backup/ratings-shows.json": traktRatings,
+		"backup/user-profile.json":  `{"username":"jonas"}`,
+		"backup/readme.txt":         "hello",
+	} {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+
+	records, unmatched, err := ReadTraktZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	assert.Nil(t, err)
+	assert.Len(t, records, 5)
+	assert.Len(t, unmatched, 1)
+}
@@ -0,0 +1,113 @@
+// Package library keeps track of the tv episodes stored on disk. it parses
+// show, season and episode from the file names, matches them on themoviedb
+// and remembers which episodes are owned.
+package library
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// file extensions treated as episodes
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".m4v": true, ".avi": true, ".mov": true,
+	".wmv": true, ".ts": true, ".webm": true, ".mpg": true, ".mpeg": true,
+}
+
+// what a file name tells us about an episode
+type Parsed struct {
+	Show     string
+	Year     int
+	Season   int
+	Episodes []int
+}
+
+var (
+	// Show.Name.S02E05.1080p.mkv, Show Name - s02e05e06 - Title.mkv
+	seasonEpisode = regexp.MustCompile(`(?i)^(.*?)[ ._-]*s(\d{1,2})[ ._-]?e(\d{1,3})(?:-?e(\d{1,3}))?(?:[^\d]|$)`)
+	// Show Name 2x05.avi
+	crossEpisode = regexp.MustCompile(`(?i)^(.*?)(?:^|[ ._-])(\d{1,2})x(\d{2,3})(?:[^\d]|$)`)
+	// Season 2, S02, Staffel 2
+	seasonDirectory = regexp.MustCompile(`(?i)^(?:season|staffel|s)[ ._-]?\d{1,2}$`)
+	// Show Name (2017), Show.Name.2017
+	trailingYear = regexp.MustCompile(`^(.*?)[ ._-]*\(?((?:19|20)\d{2})\)?$`)
+)
+
+// returns true if path looks like a video file
+func IsVideo(path string) bool {
+	return videoExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// parses show, season and episode from a path. if the file name does not contain
+// the show name, the name of the show folder is used. ok is false if the path
+// follows none of the known conventions
+func Parse(path string) (parsed Parsed, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	m := seasonEpisode.FindStringSubmatch(name)
+	if m == nil {
+		m = crossEpisode.FindStringSubmatch(name)
+	}
+	if m == nil {
+		return Parsed{}, false
+	}
+
+	parsed.Season, _ = strconv.Atoi(m[2])
+	first, _ := strconv.Atoi(m[3])
+	last := first
+	if len(m) > 4 && m[4] != "" {
+		last, _ = strconv.Atoi(m[4])
+	}
+	for episode := first; episode <= last; episode++ {
+		parsed.Episodes = append(parsed.Episodes, episode)
+	}
+
+	show := m[1]
+	if cleanName(show) == "" {
+		show = showDirectory(path)
+	}
+	parsed.Show, parsed.Year = splitYear(cleanName(show))
+	return parsed, parsed.Show != ""
+}
+
+// the folder of the show for paths like Show Name/Season 1/S01E02.mkv
+func ShowDir(path string) string {
+	dir := filepath.Dir(path)
+	if seasonDirectory.MatchString(filepath.Base(dir)) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// name of the folder of the show
+func showDirectory(path string) string {
+	dir := ShowDir(path)
+	if dir == "." || dir == string(filepath.Separator) {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+// turns Show.Name_ into Show Name
+func cleanName(name string) string {
+	name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	return strings.Join(strings.Fields(strings.Trim(name, " -")), " ")
+}
+
+func firstN(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
+
+func splitYear(name string) (string, int) {
+	m := trailingYear.FindStringSubmatch(name)
+	if m == nil || m[1] == "" {
+		return name, 0
+	}
+	year, _ := strconv.Atoi(m[2])
+	return cleanName(m[1]), year
+}
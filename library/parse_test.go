@@ -0,0 +1,47 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := map[string]Parsed{
+		"Show.Name.S02E05.1080p.mkv":                 {Show: "Show Name", Season: 2, Episodes: []int{5}},
+		"Dark - s01e03 - Past and Present.mp4":       {Show: "Dark", Season: 1, Episodes: []int{3}},
+		"The.Office.US.S01E01E02.720p.mkv":           {Show: "The Office US", Season: 1, Episodes: []int{1, 2}},
+		"Dark.2017.S01E01.mkv":                       {Show: "Dark", Year: 2017, Season: 1, Episodes: []int{1}},
+		"Doctor Who (2005) 1x02.avi":                 {Show: "Doctor Who", Year: 2005, Season: 1, Episodes: []int{2}},
+		"/tv/Game of Thrones/Season 1/S01E09.mkv":    {Show: "Game of Thrones", Season: 1, Episodes: []int{9}},
+		"/tv/Dark (2017)/Staffel 2/s02e01 Title.mkv": {Show: "Dark", Year: 2017, Season: 2, Episodes: []int{1}},
+	}
+
+	for path, expected := range tests {
+		parsed, ok := Parse(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, expected, parsed, path)
+	}
+}
+
+func TestParseUnknownConvention(t *testing.T) {
+
+	for _, path := range []string{"holiday.mp4", "Movie.1920x1080.mkv", "S01E01.mkv"} {
+		_, ok := Parse(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestIsVideo(t *testing.T) {
+
+	assert.True(t, IsVideo("Dark.S01E01.MKV"))
+	assert.False(t, IsVideo("Dark.S01E01.srt"))
+}
+
+func TestShowDir(t *testing.T) {
+
+	assert.Equal(t, filepath.FromSlash("/tv/Dark"), ShowDir(filepath.FromSlash("/tv/Dark/Season 1/S01E01.mkv")))
+	assert.Equal(t, filepath.FromSlash("/tv/Dark"), ShowDir(filepath.FromSlash("/tv/Dark/Dark.S01E01.mkv")))
+}
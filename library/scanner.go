@@ -0,0 +1,162 @@
+package library
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/resolve"
+	"bereths.com/netstar/themoviedb"
+)
+
+// a file the scanner could not match
+type Unmatched struct {
+	Path   string
+	Reason string
+}
+
+// what a scan did
+type Report struct {
+	Matched   []File
+	Unchanged int
+	Removed   int
+	Unmatched []Unmatched
+}
+
+// walks directories and matches the episode files on themoviedb
+type Scanner struct {
+	client *themoviedb.Client
+	store  *Store
+
+	// themoviedb show per parsed show name and year, so every show is searched once per scan
+	shows map[string]*themoviedb.TVShow
+}
+
+func NewScanner(client *themoviedb.Client, store *Store) *Scanner {
+	return &Scanner{client: client, store: store, shows: map[string]*themoviedb.TVShow{}}
+}
+
+// scans all dirs, updates the store and saves it. files which did not change
+// since the last scan are not looked up again
+func (s *Scanner) Scan(dirs []string) (*Report, error) {
+	report := &Report{}
+	s.shows = map[string]*themoviedb.TVShow{}
+
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := map[string]bool{}
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !IsVideo(path) {
+				return nil
+			}
+
+			seen[path] = true
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if f, ok := s.store.Get(path); ok && f.Size == info.Size() && f.ModTime.Equal(info.ModTime()) {
+				report.Unchanged++
+				return nil
+			}
+
+			f, err := s.Match(path)
+			if err != nil {
+				s.store.Remove(path)
+				report.Unmatched = append(report.Unmatched, Unmatched{path, err.Error()})
+				return nil
+			}
+
+			f.Size, f.ModTime = info.Size(), info.ModTime()
+			s.store.Put(f)
+			report.Matched = append(report.Matched, f)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// forget the files which were deleted or moved away
+		for _, f := range s.store.FilesIn(dir) {
+			if !seen[f.Path] {
+				s.store.Remove(f.Path)
+				report.Removed++
+			}
+		}
+	}
+
+	log.Printf("Scanned library: %d matched, %d unchanged, %d removed, %d unmatched", len(report.Matched), report.Unchanged, report.Removed, len(report.Unmatched))
+	return report, s.store.Save()
+}
+
+// identifies the show and episodes of a file without storing it
+func (s *Scanner) Match(path string) (File, error) {
+	parsed, ok := Parse(path)
+	if !ok {
+		return File{}, fmt.Errorf("file name follows no known convention")
+	}
+
+	show, err := s.findShow(parsed)
+	if err != nil {
+		return File{}, err
+	}
+
+	f := File{Path: path, ShowID: show.ID, ShowName: show.Name, Season: parsed.Season}
+	f.ShowYear, _ = strconv.Atoi(firstN(show.FirstAirDate, 4))
+	for _, number := range parsed.Episodes {
+		episode, err := s.client.GetEpisodeDetails(strconv.Itoa(show.ID), strconv.Itoa(parsed.Season), strconv.Itoa(number))
+		if themoviedb.IsNotFound(err) {
+			return File{}, fmt.Errorf("%s has no episode S%02dE%02d", show.Name, parsed.Season, number)
+		}
+		if err != nil {
+			return File{}, err
+		}
+		f.Episodes = append(f.Episodes, Episode{Number: number, ID: episode.ID, Name: episode.Name})
+	}
+	return f, nil
+}
+
+// searches the show and picks the best match like the resolve command does
+func (s *Scanner) findShow(parsed Parsed) (*themoviedb.TVShow, error) {
+	key := strings.ToLower(parsed.Show) + "|" + strconv.Itoa(parsed.Year)
+	if show, ok := s.shows[key]; ok {
+		if show == nil {
+			return nil, fmt.Errorf("no tv show found for %q", parsed.Show)
+		}
+		return show, nil
+	}
+
+	results, err := s.client.SearchTVShows(parsed.Show, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	query := resolve.Query{Title: parsed.Show, Year: parsed.Year}
+	var best *themoviedb.TVShow
+	bestScore := 0.0
+	for i := range results.Results {
+		if score := resolve.Score(query, results.Results[i]); score > bestScore {
+			best, bestScore = &results.Results[i], score
+		}
+	}
+
+	if bestScore < resolve.MinConfidence {
+		best = nil
+	}
+	s.shows[key] = best
+	if best == nil {
+		return nil, fmt.Errorf("no tv show found for %q", parsed.Show)
+	}
+	return best, nil
+}
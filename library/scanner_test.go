@@ -0,0 +1,75 @@
+package library
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockTMDBHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/search/tv":
+		if r.URL.Query().Get("query") == "Dark" {
+			w.Write([]byte(`{"results":[{"id":70523,"name":"Dark","first_air_date":"2017-12-01"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results":[]}`))
+	case "/tv/70523/season/1/episode/1":
+		w.Write([]byte(`{"id":1,"name":"Secrets","season_number":1,"episode_number":1}`))
+	case "/tv/70523/season/1/episode/2":
+		w.Write([]byte(`{"id":2,"name":"Lies","season_number":1,"episode_number":2}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status_code":34,"status_message":"The resource you requested could not be found."}`))
+	}
+}
+
+func CreateFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+	}
+}
+
+func TestScan(t *testing.T) {
+
+	dir := t.TempDir()
+	CreateFiles(t, dir,
+		"Dark/Season 1/Dark.S01E01.mkv",
+		"Dark/Season 1/Dark.S01E02.mkv",
+		"Dark/Season 1/Dark.S01E02.srt",
+		"Dark/Season 1/Dark.S01E99.mkv",
+		"Unknown Show/S01E01.mkv",
+		"holiday.mp4",
+	)
+
+	store, _ := OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	scanner := NewScanner(themoviedbtest.NewClient(t, MockTMDBHandler), store)
+
+	report, err := scanner.Scan([]string{dir})
+
+	assert.Nil(t, err)
+	assert.Len(t, report.Matched, 2)
+	assert.Len(t, report.Unmatched, 3)
+	assert.Equal(t, map[int]bool{1: true, 2: true}, store.Owned(70523, 1))
+
+	f, ok := store.Get(filepath.Join(dir, "Dark/Season 1/Dark.S01E02.mkv"))
+	assert.True(t, ok)
+	assert.Equal(t, "Dark", f.ShowName)
+	assert.Equal(t, []Episode{{Number: 2, ID: 2, Name: "Lies"}}, f.Episodes)
+
+	// unchanged files are not looked up again and deleted files are forgotten
+	os.Remove(filepath.Join(dir, "Dark/Season 1/Dark.S01E01.mkv"))
+	report, err = scanner.Scan([]string{dir})
+
+	assert.Nil(t, err)
+	assert.Empty(t, report.Matched)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 1, report.Removed)
+	assert.Equal(t, map[int]bool{2: true}, store.Owned(70523, 1))
+}
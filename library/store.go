@@ -0,0 +1,168 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// an episode file matched on themoviedb
+type File struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	ShowID   int       `json:"show_id"`
+	ShowName string    `json:"show_name"`
+	ShowYear int       `json:"show_year,omitempty"`
+	Season   int       `json:"season"`
+	Episodes []Episode `json:"episodes"`
+}
+
+type Episode struct {
+	Number int    `json:"number"`
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+}
+
+// the mapping of files to episodes, persisted as a json file.
+// all methods are safe for concurrent use, the reading ones also on a nil store
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	files map[string]File
+}
+
+// opens the store saved at path. a missing file is an empty library
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, files: map[string]File{}}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	if err := json.Unmarshal(b, &files); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		s.files[f.Path] = f
+	}
+	return s, nil
+}
+
+// writes the store to its file
+func (s *Store) Save() error {
+	b, err := json.MarshalIndent(s.Files(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a broken library
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *Store) Get(path string) (File, bool) {
+	if s == nil {
+		return File{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.files[path]
+	return f, ok
+}
+
+func (s *Store) Put(f File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[f.Path] = f
+}
+
+func (s *Store) Remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, path)
+}
+
+// updates the path of a file after it was moved. returns false if from is unknown
+func (s *Store) Move(from, to string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[from]
+	if !ok {
+		return false
+	}
+	delete(s.files, from)
+	f.Path = to
+	s.files[to] = f
+	return true
+}
+
+// all files sorted by path
+func (s *Store) Files() []File {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files := make([]File, 0, len(s.files))
+	for _, f := range s.files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// the files stored below dir
+func (s *Store) FilesIn(dir string) []File {
+	var files []File
+	for _, f := range s.Files() {
+		if isBelow(f.Path, dir) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// the owned episode numbers of a season of a show
+func (s *Store) Owned(showID, season int) map[int]bool {
+	owned := map[int]bool{}
+	for _, f := range s.Files() {
+		if f.ShowID != showID || f.Season != season {
+			continue
+		}
+		for _, e := range f.Episodes {
+			owned[e.Number] = true
+		}
+	}
+	return owned
+}
+
+// returns true if path is inside dir
+func isBelow(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !filepath.IsAbs(rel) && !startsWithParent(rel)
+}
+
+func startsWithParent(rel string) bool {
+	return len(rel) >= 3 && rel[:3] == ".."+string(filepath.Separator)
+}
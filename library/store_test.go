@@ -0,0 +1,62 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreSaveAndOpen(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "library.json")
+
+	store, err := OpenStore(path)
+	assert.Nil(t, err, "missing file should be an empty library")
+	assert.Empty(t, store.Files())
+
+	store.Put(File{Path: "/tv/Dark/S01E01.mkv", ShowID: 70523, Season: 1, Episodes: []Episode{{Number: 1}}})
+	store.Put(File{Path: "/tv/Dark/S01E02E03.mkv", ShowID: 70523, Season: 1, Episodes: []Episode{{Number: 2}, {Number: 3}}})
+	store.Put(File{Path: "/tv/Dark/S02E01.mkv", ShowID: 70523, Season: 2, Episodes: []Episode{{Number: 1}}})
+	assert.Nil(t, store.Save())
+
+	store, err = OpenStore(path)
+	assert.Nil(t, err)
+	assert.Len(t, store.Files(), 3)
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, store.Owned(70523, 1))
+	assert.Empty(t, store.Owned(1399, 1))
+
+	store.Remove("/tv/Dark/S02E01.mkv")
+	_, ok := store.Get("/tv/Dark/S02E01.mkv")
+	assert.False(t, ok)
+}
+
+func TestNilStore(t *testing.T) {
+
+	var store *Store
+
+	assert.Empty(t, store.Files())
+	assert.Empty(t, store.Owned(70523, 1))
+}
+
+func TestFilesIn(t *testing.T) {
+
+	store, _ := OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	store.Put(File{Path: filepath.FromSlash("/tv/Dark/S01E01.mkv")})
+	store.Put(File{Path: filepath.FromSlash("/tv2/Dark/S01E01.mkv")})
+
+	assert.Len(t, store.FilesIn(filepath.FromSlash("/tv")), 1)
+}
+
+func TestStoreMove(t *testing.T) {
+
+	store, _ := OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	store.Put(File{Path: "/tv/dark.s01e01.mkv", ShowID: 70523})
+
+	assert.True(t, store.Move("/tv/dark.s01e01.mkv", "/tv/Dark/Dark - S01E01.mkv"))
+	assert.False(t, store.Move("/tv/unknown.mkv", "/tv/other.mkv"))
+
+	f, ok := store.Get("/tv/Dark/Dark - S01E01.mkv")
+	assert.True(t, ok)
+	assert.Equal(t, "/tv/Dark/Dark - S01E01.mkv", f.Path)
+}
@@ -2,52 +2,300 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
-
+	"unicode"
+
+	"bereths.com/netstar/i18n"
+	"bereths.com/netstar/imagecache"
+	"bereths.com/netstar/library"
+	"bereths.com/netstar/notify"
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/ratings"
 	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
 	"github.com/gorilla/mux"
 	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
 )
 
+// the texts of the interface in every language there is a catalog for
+var messages = i18n.Must(i18n.Load("locales", "en"))
+
+// translate message ids in templates: {{ t $ "nav.watchlist" }} and {{ tn $ "reviews.votes" .Count }}
+var templateFuncs = template.FuncMap{
+	"t": func(page *Page, id string, args ...interface{}) string {
+		return page.Locale.T(id, args...)
+	},
+	"tn": func(page *Page, id string, n int, args ...interface{}) string {
+		return page.Locale.N(id, n, args...)
+	},
+	// whole sentences with a link: {{ tlink $ "watchlist.empty" "/" }}
+	"tlink": func(page *Page, id, href string) template.HTML {
+		return linkText(page.Locale.T(id), href)
+	},
+}
+
+// text with the part in square brackets, like "add it to your [watchlist]", linked to href.
+// messages mark the link instead of being split around it, so every language keeps its word order
+func linkText(text, href string) template.HTML {
+	start := strings.Index(text, "[")
+	end := strings.Index(text, "]")
+	if start < 0 || end < start {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+	return template.HTML(template.HTMLEscapeString(text[:start]) +
+		`<a href="` + template.HTMLEscapeString(href) + `">` + template.HTMLEscapeString(text[start+1:end]) + `</a>` +
+		template.HTMLEscapeString(text[end+1:]))
+}
+
+func parsePage(files ...string) *template.Template {
+	return template.Must(template.New(filepath.Base(files[0])).Funcs(templateFuncs).ParseFiles(files...))
+}
+
 // declare template
-var index = template.Must(template.ParseFiles("pages/index.html", "pages/base.html"))
-var details = template.Must(template.ParseFiles("pages/details.html", "pages/base.html"))
-var seasonDetails = template.Must(template.ParseFiles("pages/season_details.html", "pages/base.html"))
-var episodeDetails = template.Must(template.ParseFiles("pages/episode_details.html", "pages/base.html"))
+var index = parsePage("pages/index.html", "pages/base.html")
+var details = parsePage("pages/details.html", "pages/reviews.html", "pages/videos.html", "pages/rails.html", "pages/base.html")
+var seasonDetails = parsePage("pages/season_details.html", "pages/reviews.html", "pages/videos.html", "pages/base.html")
+var episodeDetails = parsePage("pages/episode_details.html", "pages/reviews.html", "pages/base.html")
+var galleryPage = parsePage("pages/gallery.html", "pages/base.html")
+var login = parsePage("pages/login.html", "pages/base.html")
+var register = parsePage("pages/register.html", "pages/base.html")
+var watchlistPage = parsePage("pages/watchlist.html", "pages/base.html")
+var recommendationsPage = parsePage("pages/recommendations.html", "pages/rails.html", "pages/base.html")
+var continueWatching = parsePage("pages/continue.html", "pages/base.html")
+var calendarPage = parsePage("pages/calendar.html", "pages/base.html")
+var importPage = parsePage("pages/import.html", "pages/base.html")
+
+// what every page is rendered with. the content templates find their data in .Data
+type Page struct {
+	User        *users.User
+	CSRFToken   string
+	Preferences *Preferences
+	// the texts of the interface in the language of the visitor
+	Locale *i18n.Localizer
+	Data   interface{}
+}
 
+// a page of search results. themoviedb pages the results before the certification filter hides
+// shows, so the totals count the unfiltered results and pages can come out shorter than others
 type Search struct {
 	Query      string
 	NextPage   int
 	TotalPages int
 	Results    *themoviedb.Results
+	// how many results of this page the certification filter hid
+	Hidden int
 }
 
 type Config struct {
-	API_KEY      string `mapstructure:API_KEY`
-	Language     string `mapstructure:LANGUAGE`
-	IncludeAdult bool   `mapstructure:INCLUDE_ADULT`
-	Port         string `mapstructure:PORT`
+	API_KEY  string `mapstructure:"API_KEY"`
+	APIURL   string `mapstructure:"API_URL"`
+	Language string `mapstructure:"LANGUAGE"`
+	// the country streaming providers are shown for, like DE
+	Region       string `mapstructure:"REGION"`
+	IncludeAdult bool   `mapstructure:"INCLUDE_ADULT"`
+	Port         string `mapstructure:"PORT"`
+	LibraryDirs  string `mapstructure:"LIBRARY_DIRS"`
+	LibraryIndex string `mapstructure:"LIBRARY_INDEX"`
+	Database     string `mapstructure:"DATABASE"`
+	// set if netstar is served over https
+	SecureCookies bool `mapstructure:"SECURE_COOKIES"`
+
+	OrganizeTemplate string `mapstructure:"ORGANIZE_TEMPLATE"`
+
+	// where new episodes of watchlisted shows are posted to, separated by spaces or commas
+	WebhookURLs     string `mapstructure:"WEBHOOK_URLS"`
+	WebhookSecret   string `mapstructure:"WEBHOOK_SECRET"`
+	WebhookInterval string `mapstructure:"WEBHOOK_INTERVAL"`
+
+	// where the images of themoviedb are cached and how many megabytes they may take
+	ImageCache     string `mapstructure:"IMAGE_CACHE"`
+	ImageCacheSize int64  `mapstructure:"IMAGE_CACHE_SIZE"`
+
+	// the highest certification in REGION shown in search results and recommendations, like TV-14 or 12
+	MaxCertification string `mapstructure:"MAX_CERTIFICATION"`
+	// keeps shows without a certification in REGION while MAX_CERTIFICATION is set
+	KeepUnrated bool `mapstructure:"KEEP_UNRATED"`
+
+	// the languages names and overviews missing in LANGUAGE are taken from, in order
+	FallbackLanguages string `mapstructure:"FALLBACK_LANGUAGES"`
+}
+
+// everything the handlers depend on
+type App struct {
+	TMDB      *themoviedb.Client
+	Library   *library.Store
+	Users     *users.Manager
+	Watchlist *watchlist.Store
+	Progress  *progress.Store
+	Notify    *notify.Store
+	Ratings   *ratings.Store
+	// caches the images of themoviedb, they are fetched by the browsers directly if nil
+	Images *imagecache.Proxy
+	// the default country of the providers, users can choose their own
+	Region string
+	// hides shows for older audiences from search results and recommendations, nil to show all
+	Certifications *CertificationFilter
+}
+
+// the show, whether the user saved it and how far the user is
+type DetailsPage struct {
+	*themoviedb.TVShowDetails
+	OnWatchlist    bool
+	Progress       *progress.Progress
+	SeasonProgress map[int]progress.Progress
+	Reviews        *Reviews
+	Providers      *ProvidersSection
+	Videos         []themoviedb.Video
+	Certification  *CertificationSection
+	Keywords       []themoviedb.Keyword
+	// recommended and similar shows
+	Rails []Rail
+}
+
+// the season with the episodes we have on disk and the user has watched
+type SeasonPage struct {
+	*themoviedb.TVSeasonDetails
+	Owned    map[int]bool
+	Watched  map[int]bool
+	Progress *progress.Progress
+	Reviews  *Reviews
+	Videos   []themoviedb.Video
+}
+
+// the episode with the show it belongs to
+type EpisodePage struct {
+	*themoviedb.TVEpisodeDetails
+	TVID    int
+	Reviews *Reviews
 }
 
 // define the route urls here
 func NewRouter(themoviedbAPI *themoviedb.Client) *mux.Router {
+	return NewAppRouter(&App{TMDB: themoviedbAPI})
+}
+
+func NewAppRouter(app *App) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(PreferencesMiddleware(app))
 	// index
 	r.HandleFunc("/", IndexHandler).Methods("GET")
 	// search like /search?q=Star Wars
-	r.HandleFunc("/search", SearchHandler(themoviedbAPI)).Methods("GET")
+	r.HandleFunc("/search", localized(app, func(app *App) http.HandlerFunc {
+		return SearchHandler(app.TMDB, app.Certifications)
+	})).Methods("GET")
 	// details like /search?id=1337
-	r.HandleFunc("/details", TVShowDetailsHandler(themoviedbAPI)).Methods("GET")
+	r.HandleFunc("/details", localized(app, TVShowDetailsHandler)).Methods("GET")
 	// details for seasion like /search?id=1337&seasonNumber=1
-	r.HandleFunc("/details/season", SeasonDetailsHandler(themoviedbAPI)).Methods("GET")
+	r.HandleFunc("/details/season", localized(app, SeasonDetailsHandler)).Methods("GET")
 	// details for episode like /details/episode?id=1337&seasonNumber=1&episodeNumber=4
-	r.HandleFunc("/details/episode", EpisodeDetailsHandler(themoviedbAPI)).Methods("GET")
+	r.HandleFunc("/details/episode", localized(app, EpisodeDetailsHandler)).Methods("GET")
+	// posters, backdrops and stills like /details/images?id=1337&seasonNumber=1&lang=de
+	r.HandleFunc("/details/images", localizedClient(app, GalleryHandler)).Methods("GET")
+	// the images of themoviedb like /img/w342/abc.jpg
+	r.HandleFunc(imagePath+"/{size}/{file}", ImageHandler(app.Images)).Methods("GET")
+	// recently aired episodes like /feeds/shows/1337.atom or .rss
+	r.HandleFunc("/feeds/shows/{id:[0-9]+}.{format:atom|rss}", localizedClient(app, ShowFeedHandler)).Methods("GET")
+	// jump to the page of an imdb, tvdb or wikidata id like /find/imdb/tt5753856
+	r.HandleFunc("/find/{source:imdb|tvdb|wikidata}/{id}", localizedClient(app, FindHandler)).Methods("GET")
+	// the same as json and the details with their external ids
+	r.HandleFunc("/api/find/{source:imdb|tvdb|wikidata}/{id}", localizedClient(app, APIFindHandler)).Methods("GET")
+	r.HandleFunc("/api/shows/{id:[0-9]+}", localizedClient(app, APIShowHandler)).Methods("GET")
+	r.HandleFunc("/api/shows/{id:[0-9]+}/seasons/{season:[0-9]+}/episodes/{episode:[0-9]+}", localizedClient(app, APIEpisodeHandler)).Methods("GET")
+
+	// the language switcher
+	r.HandleFunc("/settings/preferences", PreferencesHandler(app)).Methods("POST")
+
+	// accounts, only if there is a database to store them
+	if app.Users != nil {
+		app.Users.Error = httpError
+		r.Use(app.Users.Middleware)
+		r.HandleFunc("/login", LoginPageHandler).Methods("GET")
+		r.HandleFunc("/login", LoginHandler(app.Users)).Methods("POST")
+		r.HandleFunc("/register", RegisterPageHandler).Methods("GET")
+		r.HandleFunc("/register", RegisterHandler(app.Users)).Methods("POST")
+		r.HandleFunc("/logout", LogoutHandler(app.Users)).Methods("POST")
+
+		r.HandleFunc("/settings/region", RegionHandler(app.Users)).Methods("POST")
+
+		if app.Watchlist != nil {
+			r.HandleFunc("/watchlist", localized(app, func(app *App) http.HandlerFunc {
+				return WatchlistPageHandler(app.TMDB, app.Watchlist, app.Users)
+			})).Methods("GET")
+			r.HandleFunc("/watchlist", localized(app, func(app *App) http.HandlerFunc {
+				return AddToWatchlistHandler(app.TMDB, app.Watchlist)
+			})).Methods("POST")
+			r.HandleFunc("/watchlist/remove", RemoveFromWatchlistHandler(app.Watchlist)).Methods("POST")
+			r.HandleFunc("/api/watchlist", localized(app, func(app *App) http.HandlerFunc {
+				return APIWatchlistHandler(app.TMDB, app.Watchlist)
+			})).Methods("GET")
+			r.HandleFunc("/api/watchlist/{id:[0-9]+}", localized(app, func(app *App) http.HandlerFunc {
+				return APIAddToWatchlistHandler(app.TMDB, app.Watchlist)
+			})).Methods("PUT")
+			r.HandleFunc("/api/watchlist/{id:[0-9]+}", APIRemoveFromWatchlistHandler(app.Watchlist)).Methods("DELETE")
+
+			// shows like the ones on the watchlist
+			r.HandleFunc("/recommendations", localized(app, func(app *App) http.HandlerFunc {
+				return RecommendationsHandler(app.TMDB, app.Watchlist, app.Certifications)
+			})).Methods("GET")
+
+			// upcoming episodes of the watchlist, the feed is found by its secret url
+			r.HandleFunc("/calendar", localized(app, func(app *App) http.HandlerFunc {
+				return CalendarPageHandler(app.TMDB, app.Watchlist, app.Users)
+			})).Methods("GET")
+			r.HandleFunc("/calendar/reset", ResetCalendarHandler(app.Users)).Methods("POST")
+			r.HandleFunc("/calendar/{token}.ics", localized(app, func(app *App) http.HandlerFunc {
+				return CalendarFeedHandler(app.TMDB, app.Watchlist, app.Users)
+			})).Methods("GET")
+			r.HandleFunc("/feeds/watchlist/{token}.{format:atom|rss}", localized(app, func(app *App) http.HandlerFunc {
+				return WatchlistFeedHandler(app.TMDB, app.Watchlist, app.Users)
+			})).Methods("GET")
+		}
+
+		if app.Ratings != nil {
+			r.HandleFunc("/reviews", localized(app, func(app *App) http.HandlerFunc {
+				return ReviewHandler(app.TMDB, app.Ratings)
+			})).Methods("POST")
+			r.HandleFunc("/reviews/delete", DeleteReviewHandler(app.Ratings)).Methods("POST")
+			r.HandleFunc("/api/ratings/top", APITopRatedHandler(app.Ratings)).Methods("GET")
+		}
+
+		if app.Watchlist != nil && app.Progress != nil && app.Ratings != nil {
+			r.HandleFunc("/import", ImportPageHandler).Methods("GET")
+			r.HandleFunc("/import", localized(app, ImportHandler)).Methods("POST")
+			r.HandleFunc("/import/backup", localized(app, RestoreHandler)).Methods("POST")
+			r.HandleFunc("/export.{format:json|csv}", localized(app, ExportHandler)).Methods("GET")
+		}
+
+		if app.Notify != nil {
+			r.HandleFunc("/api/webhooks/deliveries", APIDeliveriesHandler(app.Notify)).Methods("GET")
+		}
+
+		if app.Progress != nil {
+			r.HandleFunc("/continue", localized(app, func(app *App) http.HandlerFunc {
+				return ContinueWatchingHandler(app.TMDB, app.Progress)
+			})).Methods("GET")
+			r.HandleFunc("/progress/episode", MarkEpisodeHandler(app.Progress)).Methods("POST")
+			r.HandleFunc("/progress/season", localized(app, func(app *App) http.HandlerFunc {
+				return MarkSeasonHandler(app.TMDB, app.Progress)
+			})).Methods("POST")
+			r.HandleFunc("/progress/upto", localized(app, func(app *App) http.HandlerFunc {
+				return MarkUpToHandler(app.TMDB, app.Progress)
+			})).Methods("POST")
+		}
+	}
 
 	// declare static files
 	staticFileDirectory := http.Dir("./assets/")
@@ -58,10 +306,12 @@ func NewRouter(themoviedbAPI *themoviedb.Client) *mux.Router {
 	return r
 }
 
-// index page
-func IndexHandler(w http.ResponseWriter, r *http.Request) {
+// renders the "base" layout of t with data and the current user
+func render(w http.ResponseWriter, r *http.Request, t *template.Template, data interface{}) {
+	page := &Page{User: users.CurrentUser(r), CSRFToken: users.CSRFToken(r), Preferences: requestPreferences(r), Locale: requestLocale(r), Data: data}
+
 	buf := &bytes.Buffer{}
-	err := index.ExecuteTemplate(w, "base", nil)
+	err := t.ExecuteTemplate(buf, "base", page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -70,8 +320,80 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	buf.WriteTo(w)
 }
 
+// the language of the interface: the choice of the language switcher, else the Accept-Language
+// header, else english. unlike the texts of themoviedb it does not default to LANGUAGE
+func requestLocale(r *http.Request) *i18n.Localizer {
+	var choice string
+	if c, err := r.Cookie(languageCookie); err == nil {
+		choice = c.Value
+	}
+	return messages.Localizer(choice, r.Header.Get("Accept-Language"))
+}
+
+var (
+	errInvalidID       = errors.New("invalid show id")
+	errInvalidRating   = errors.New("invalid rating")
+	errUnknownLanguage = errors.New("unknown language")
+)
+
+// the message ids of the errors visitors can run into
+var errorMessages = map[error]string{
+	users.ErrInvalidCredentials: "error.invalid_credentials",
+	users.ErrUsernameTaken:      "error.username_taken",
+	users.ErrInvalidUsername:    "error.invalid_username",
+	users.ErrPasswordTooShort:   "error.password_too_short",
+	errNoExport:                 "error.no_export",
+	errUnknownExport:            "error.unknown_export",
+	users.ErrInvalidCSRF:        "error.invalid_csrf",
+	errInvalidID:                "error.invalid_id",
+	errInvalidRating:            "error.invalid_rating",
+	errUnknownLanguage:          "error.unknown_language",
+	errNothingFound:             "error.nothing_found",
+}
+
+// what is shown on pages for err: its message id, or its text if it has no message
+func errorMessage(err error) string {
+	for e, id := range errorMessages {
+		if errors.Is(err, e) {
+			return id
+		}
+	}
+	return err.Error()
+}
+
+// answers a failed request with the text of err in the language of the visitor
+func httpError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	http.Error(w, requestLocale(r).T(errorMessage(err)), status)
+}
+
+// whether a fetch for an optional section of a page, like the trailers or the providers,
+// succeeded. pages work without these sections, so a failed fetch is only logged
+func fetched(err error, format string, args ...interface{}) bool {
+	if err != nil {
+		log.Printf("Could not load "+format+": %v", append(args, err)...)
+		return false
+	}
+	return true
+}
+
+// the http status to answer with if a handler failed with err
+func errorStatus(err error) int {
+	switch {
+	case err == errInvalidID:
+		return http.StatusBadRequest
+	case themoviedb.IsNotFound(err):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// index page
+func IndexHandler(w http.ResponseWriter, r *http.Request) {
+	render(w, r, index, &Search{})
+}
+
 // handles the search a user executes
-func SearchHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+func SearchHandler(themoviedbAPI *themoviedb.Client, filter *CertificationFilter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		u, err := url.Parse(r.URL.String())
 		if err != nil {
@@ -92,6 +414,9 @@ func SearchHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
 			return
 		}
 
+		found := len(results.Results)
+		results.Results = filter.Filter(results.Results)
+
 		log.Println("Search Query is: ", searchQuery)
 		log.Println("Page is: ", page)
 		nextPage, err := strconv.Atoi(page)
@@ -103,23 +428,18 @@ func SearchHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
 		search := &Search{
 			Query:      searchQuery,
 			NextPage:   nextPage,
-			TotalPages: results.TotalResults,
+			TotalPages: results.TotalPages,
 			Results:    results,
+			Hidden:     found - len(results.Results),
 		}
 
-		buf := &bytes.Buffer{}
-		err = index.ExecuteTemplate(w, "base", search)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		buf.WriteTo(w)
+		render(w, r, index, search)
 	}
 }
 
 // handles the tv show details if a user clicks on a tv show
-func TVShowDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+func TVShowDetailsHandler(app *App) http.HandlerFunc {
+	themoviedbAPI := app.TMDB
 	return func(w http.ResponseWriter, r *http.Request) {
 		u, err := url.Parse(r.URL.String())
 		if err != nil {
@@ -132,23 +452,49 @@ func TVShowDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
 
 		results, err := themoviedbAPI.GetTVShowDetails(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			httpError(w, r, err, errorStatus(err))
 			return
 		}
 
-		buf := &bytes.Buffer{}
-		err = details.ExecuteTemplate(w, "base", results)
+		page := &DetailsPage{TVShowDetails: results}
+		if user := users.CurrentUser(r); user != nil {
+			if app.Watchlist != nil {
+				page.OnWatchlist = app.Watchlist.Has(user.ID, results.ID)
+			}
+			if app.Progress != nil {
+				watched, err := app.Progress.Watched(user.ID, results.ID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				total, seasons := progress.ShowProgress(results, watched)
+				page.Progress, page.SeasonProgress = &total, seasons
+			}
+		}
+
+		page.Reviews, err = loadReviews(app.Ratings, r, ratings.Target{Kind: ratings.Show, ShowID: results.ID}, results.VoteAverage, results.VoteCount)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		buf.WriteTo(w)
+		// the other sections each ask themoviedb on their own
+		sections := []func(){
+			func() { page.Providers = loadProviders(app, r, results.ID) },
+			func() { page.Videos = loadVideos(themoviedbAPI.GetTVShowVideos(id)) },
+			func() { page.Certification = loadCertification(app, r, results.ID) },
+			func() { page.Keywords = loadKeywords(themoviedbAPI, results.ID) },
+			func() { page.Rails = loadRails(themoviedbAPI, app.Certifications, id) },
+		}
+		themoviedb.Parallel(len(sections), func(i int) { sections[i]() })
+
+		render(w, r, details, page)
 	}
 }
 
 // handles the season details if a user klicks on a season
-func SeasonDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+func SeasonDetailsHandler(app *App) http.HandlerFunc {
+	themoviedbAPI := app.TMDB
 	return func(w http.ResponseWriter, r *http.Request) {
 		u, err := url.Parse(r.URL.String())
 		if err != nil {
@@ -162,23 +508,36 @@ func SeasonDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
 
 		result, err := themoviedbAPI.GetSeasonDetails(id, seasonNumber)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			httpError(w, r, err, errorStatus(err))
 			return
 		}
 
-		buf := &bytes.Buffer{}
-		err = seasonDetails.ExecuteTemplate(w, "base", result)
+		page := &SeasonPage{TVSeasonDetails: result, Owned: app.Library.Owned(result.TVID, result.SeasonNumber)}
+		if user := users.CurrentUser(r); user != nil && app.Progress != nil {
+			watched, err := app.Progress.Watched(user.ID, result.TVID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			p := progress.SeasonProgress(result, watched)
+			page.Watched, page.Progress = watched.Episodes(result.SeasonNumber), &p
+		}
+
+		page.Reviews, err = loadReviews(app.Ratings, r, ratings.Target{Kind: ratings.Season, ShowID: result.TVID, Season: result.SeasonNumber}, 0, 0)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		buf.WriteTo(w)
+		page.Videos = loadVideos(themoviedbAPI.GetSeasonVideos(id, seasonNumber))
+
+		render(w, r, seasonDetails, page)
 	}
 }
 
 // handles the episode a user clicks
-func EpisodeDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
+func EpisodeDetailsHandler(app *App) http.HandlerFunc {
+	themoviedbAPI := app.TMDB
 	return func(w http.ResponseWriter, r *http.Request) {
 		u, err := url.Parse(r.URL.String())
 		if err != nil {
@@ -193,18 +552,21 @@ func EpisodeDetailsHandler(themoviedbAPI *themoviedb.Client) http.HandlerFunc {
 
 		result, err := themoviedbAPI.GetEpisodeDetails(id, seasonNumber, episodeNumber)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			httpError(w, r, err, errorStatus(err))
 			return
 		}
 
-		buf := &bytes.Buffer{}
-		err = episodeDetails.ExecuteTemplate(w, "base", result)
+		page := &EpisodePage{TVEpisodeDetails: result}
+		page.TVID, _ = strconv.Atoi(id)
+
+		target := ratings.Target{Kind: ratings.Episode, ShowID: page.TVID, Season: result.SeasonNumber, Episode: result.EpisodeNumber}
+		page.Reviews, err = loadReviews(app.Ratings, r, target, result.VoteAverage, result.VoteCount)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		buf.WriteTo(w)
+		render(w, r, episodeDetails, page)
 	}
 }
 
@@ -215,6 +577,7 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
 	viper.AutomaticEnv()
+	bindConfigEnv()
 
 	err = viper.ReadInConfig()
 	if err != nil {
@@ -226,6 +589,95 @@ func LoadConfig(path string) (config Config, err error) {
 
 }
 
+// lets AutomaticEnv fill every field of Config. viper only unmarshals keys it knows,
+// which are the ones in the .env file unless they are bound
+func bindConfigEnv() {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("mapstructure"); key != "" {
+			viper.BindEnv(key)
+		}
+	}
+}
+
+// the directories the library scanner walks
+func (c Config) libraryDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(c.LibraryDirs) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// the bolt database holding users and their data
+func (c Config) database() string {
+	if c.Database == "" {
+		return "netstar.db"
+	}
+	return c.Database
+}
+
+func (c Config) webhookURLs() []string {
+	return strings.FieldsFunc(c.WebhookURLs, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// how often tracked shows are checked for news, every 6 hours by default
+func (c Config) webhookInterval() (time.Duration, error) {
+	if c.WebhookInterval == "" {
+		return 6 * time.Hour, nil
+	}
+	return time.ParseDuration(c.WebhookInterval)
+}
+
+// the country of the providers: REGION, else the country of LANGUAGE
+func (c Config) region() string {
+	if c.Region != "" {
+		return strings.ToUpper(c.Region)
+	}
+	if _, country, ok := strings.Cut(c.Language, "-"); ok {
+		return strings.ToUpper(country)
+	}
+	return defaultRegion
+}
+
+// the languages texts missing in LANGUAGE are taken from, en-US by default
+func (c Config) fallbackLanguages() []string {
+	if c.FallbackLanguages == "" {
+		return []string{"en-US"}
+	}
+	return strings.FieldsFunc(c.FallbackLanguages, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// the directory of the image cache
+func (c Config) imageCache() string {
+	if c.ImageCache == "" {
+		return "image-cache"
+	}
+	return c.ImageCache
+}
+
+// the size limit of the image cache in bytes, 512 MB by default
+func (c Config) imageCacheSize() int64 {
+	if c.ImageCacheSize <= 0 {
+		return 512 << 20
+	}
+	return c.ImageCacheSize << 20
+}
+
+// the file the library is saved in
+func (c Config) libraryIndex() string {
+	if c.LibraryIndex == "" {
+		return "library.json"
+	}
+	return c.LibraryIndex
+}
+
 func main() {
 
 	f, err := os.OpenFile("netstar.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
@@ -236,6 +688,15 @@ func main() {
 
 	log.SetOutput(f)
 
+	// run a cli command like "netstar search Dark" instead of the server
+	if len(os.Args) > 1 {
+		if _, ok := commands[os.Args[1]]; ok {
+			code := RunCLI(os.Args[1:], os.Stdout, os.Stderr)
+			f.Close()
+			os.Exit(code)
+		}
+	}
+
 	config, err := LoadConfig(".")
 
 	if err != nil {
@@ -244,9 +705,101 @@ func main() {
 
 	themoviedbClient := &http.Client{Timeout: 10 * time.Second}
 	themoviedbAPI := themoviedb.NewClient(themoviedbClient, config.API_KEY, config.Language, config.IncludeAdult)
+	if config.APIURL != "" {
+		themoviedbAPI.SetBaseURL(config.APIURL)
+	}
+	themoviedbAPI.SetFallbackLanguages(config.fallbackLanguages()...)
+
+	lib, err := library.OpenStore(config.libraryIndex())
+	if err != nil {
+		log.Fatalf("Could not open library: %v", err)
+	}
+
+	// match new episode files in the background while already serving
+	if dirs := config.libraryDirs(); len(dirs) > 0 {
+		go func() {
+			if _, err := library.NewScanner(themoviedbAPI, lib).Scan(dirs); err != nil {
+				log.Printf("Could not scan library: %v", err)
+			}
+		}()
+	}
+
+	db, err := bolt.Open(config.database(), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		log.Fatalf("Could not open database: %v", err)
+	}
+	defer db.Close()
+
+	userStore, err := users.NewStore(db)
+	if err != nil {
+		log.Fatalf("Could not open users: %v", err)
+	}
+
+	wl, err := watchlist.NewStore(db)
+	if err != nil {
+		log.Fatalf("Could not open watchlist: %v", err)
+	}
+
+	pr, err := progress.NewStore(db)
+	if err != nil {
+		log.Fatalf("Could not open progress: %v", err)
+	}
+
+	ratingStore, err := ratings.NewStore(db)
+	if err != nil {
+		log.Fatalf("Could not open ratings: %v", err)
+	}
+
+	notifyStore, err := notify.NewStore(db)
+	if err != nil {
+		log.Fatalf("Could not open webhook log: %v", err)
+	}
+
+	// tell the webhooks about new episodes of watchlisted shows
+	if urls := config.webhookURLs(); len(urls) > 0 {
+		interval, err := config.webhookInterval()
+		if err != nil {
+			log.Fatalf("Invalid WEBHOOK_INTERVAL: %v", err)
+		}
+		scheduler := &notify.Scheduler{
+			TMDB:     themoviedbAPI,
+			Shows:    wl,
+			Store:    notifyStore,
+			Sender:   notify.NewSender(urls, config.WebhookSecret, notifyStore),
+			Interval: interval,
+		}
+		go scheduler.Run(context.Background())
+	}
+
+	imageCache, err := imagecache.Open(config.imageCache(), config.imageCacheSize())
+	if err != nil {
+		log.Fatalf("Could not open image cache: %v", err)
+	}
+	images := imagecache.NewProxy(imageCache, &http.Client{Timeout: 30 * time.Second}, themoviedb.ImageBaseURL)
+
+	var certifications *CertificationFilter
+	if config.MaxCertification != "" {
+		certifications = NewCertificationFilter(themoviedbAPI, config.region(), config.MaxCertification)
+		certifications.KeepUnrated = config.KeepUnrated
+		if err := certifications.Check(); err != nil {
+			log.Fatalf("MAX_CERTIFICATION: %v", err)
+		}
+	}
 
 	// declare router
-	r := NewRouter(themoviedbAPI)
+	r := NewAppRouter(&App{
+		TMDB:      themoviedbAPI,
+		Library:   lib,
+		Users:     users.NewManager(userStore, config.SecureCookies),
+		Watchlist: wl,
+		Progress:  pr,
+		Notify:    notifyStore,
+		Ratings:   ratingStore,
+		Images:    images,
+		Region:    config.region(),
+
+		Certifications: certifications,
+	})
 
 	// serve
 	http.ListenAndServe(":"+config.Port, r)
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"bereths.com/netstar/library"
 	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"bereths.com/netstar/users"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,7 +112,7 @@ func TestSearchHandlerWithInvalidAPIKey(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	hf := http.HandlerFunc(SearchHandler(themoviedbAPI))
+	hf := http.HandlerFunc(SearchHandler(themoviedbAPI, nil))
 
 	hf.ServeHTTP(recorder, request)
 
@@ -225,6 +235,167 @@ func ExecuteURL(url string, t *testing.T) {
 	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError, "Status should be %d, got %d", http.StatusInternalServerError, resp.StatusCode)
 }
 
+func TestSeasonDetailsHandlerMarksOwnedEpisodes(t *testing.T) {
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Season 1","season_number":1,"episodes":[{"episode_number":1,"name":"Secrets"},{"episode_number":2,"name":"Lies"}]}`))
+	}))
+	defer tmdbServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+
+	lib, _ := library.OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	lib.Put(library.File{Path: "/tv/Dark/S01E02.mkv", ShowID: 70523, Season: 1, Episodes: []library.Episode{{Number: 2}}})
+
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI, Library: lib}))
+	defer mockServer.Close()
+
+	resp, err := http.Get(mockServer.URL + "/details/season?id=70523&seasonNumber=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, strings.Count(string(body), ">owned<"), "only episode 2 should be marked as owned")
+}
+
+func TestSeasonDetailsMarkFallbackTexts(t *testing.T) {
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("language") == "en-US" {
+			w.Write([]byte(`{"name":"Season 1","overview":"A child goes missing.","season_number":1,"episodes":[{"episode_number":1,"name":"Secrets"},{"episode_number":2,"name":"Lies"}]}`))
+			return
+		}
+		w.Write([]byte(`{"name":"Staffel 1","air_date":"2017-12-01","season_number":1,"episodes":[{"episode_number":1,"air_date":"2017-12-01","name":"Geheimnisse"},{"episode_number":2,"air_date":"2017-12-01","name":""}]}`))
+	}))
+	defer tmdbServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	themoviedbAPI.SetFallbackLanguages("en-US")
+	mockServer := httptest.NewServer(NewRouter(themoviedbAPI))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details/season?id=70523&seasonNumber=1")
+	body := ReadBody(t, resp)
+
+	assert.Contains(t, body, `>Staffel 1</p>`)
+	assert.Contains(t, body, `<p lang="en-US">A child goes missing.`)
+	assert.Contains(t, body, `lang="en-US">Lies</a>`)
+	assert.Contains(t, body, `>Geheimnisse</a>`)
+	assert.Equal(t, 2, strings.Count(body, `title="Not translated yet, shown in en-US"`))
+}
+
+func TestConfigFallbackLanguages(t *testing.T) {
+
+	assert.Equal(t, []string{"en-US"}, Config{}.fallbackLanguages())
+	assert.Equal(t, []string{"de-AT", "en-GB"}, Config{FallbackLanguages: "de-AT, en-GB"}.fallbackLanguages())
+}
+
+func TestPagesAreTranslated(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+
+	get := func(acceptLanguage, choice string) string {
+		request, _ := http.NewRequest("GET", mockServer.URL+"/login", nil)
+		request.Header.Set("Accept-Language", acceptLanguage)
+		if choice != "" {
+			request.AddCookie(&http.Cookie{Name: languageCookie, Value: choice})
+		}
+		resp, err := client.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ReadBody(t, resp)
+	}
+
+	body := get("", "")
+	assert.Contains(t, body, `<html lang="en">`)
+	assert.Contains(t, body, "No account yet?")
+
+	body = get("de-CH, de;q=0.9", "")
+	assert.Contains(t, body, `<html lang="de">`)
+	assert.Contains(t, body, "Noch kein Konto?")
+	assert.Contains(t, body, ">Anmelden</button>")
+
+	assert.Contains(t, get("ja-JP", ""), `<html lang="en">`, "languages without a catalog should fall back to english")
+	assert.Contains(t, get("de", "en-US"), `<html lang="en">`, "the choice of the switcher should win")
+
+	token := GetCSRFToken(t, client, mockServer.URL+"/register")
+	request, _ := http.NewRequest("POST", mockServer.URL+"/register", strings.NewReader(url.Values{
+		"csrf_token":            {token},
+		"username":              {"Jonas"},
+		"password":              {"winter is coming"},
+		"password_confirmation": {"summer is coming"},
+	}.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept-Language", "de")
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, ReadBody(t, resp), "Die Passwörter stimmen nicht überein")
+}
+
+func TestDetailsLoadSectionsConcurrently(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	themoviedbAPI := themoviedbtest.NewClient(t, concurrency.Slow(DarkHandler))
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI, Region: "DE"}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Dark")
+	assert.Greater(t, concurrency.Max(), 1)
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
+
+func TestErrorMessage(t *testing.T) {
+
+	assert.Equal(t, "error.username_taken", errorMessage(users.ErrUsernameTaken))
+	assert.Equal(t, "error.unknown_export", errorMessage(fmt.Errorf("dark.txt: %w", errUnknownExport)))
+	assert.Equal(t, "line 3: invalid rating", errorMessage(errors.New("line 3: invalid rating")))
+}
+
+func TestErrorsAreTranslated(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+
+	request, _ := http.NewRequest("POST", mockServer.URL+"/logout", nil)
+	request.Header.Set("Accept-Language", "de")
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "Das Formular ist abgelaufen")
+
+	request, _ = http.NewRequest("POST", mockServer.URL+"/settings/preferences", strings.NewReader(url.Values{"lang": {"xx-XX"}}.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.AddCookie(&http.Cookie{Name: languageCookie, Value: "de-DE"})
+	request.AddCookie(&http.Cookie{Name: "netstar_csrf", Value: strings.Repeat("a", 32)})
+	request.Header.Set("X-CSRF-Token", strings.Repeat("a", 32))
+	resp, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, ReadBody(t, resp), "Unbekannte Sprache")
+}
+
+func TestLinkText(t *testing.T) {
+
+	assert.Equal(t, template.HTML(`Add shows to your <a href="/watchlist">watchlist</a> &amp; more.`), linkText("Add shows to your [watchlist] & more.", "/watchlist"))
+	assert.Equal(t, template.HTML(`no link`), linkText("no link", "/"))
+}
+
 func TestRunMain(t *testing.T) {
 	main()
 }
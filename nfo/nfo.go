@@ -0,0 +1,237 @@
+// Package nfo writes Kodi/Jellyfin compatible tvshow.nfo and episode .nfo
+// files plus artwork next to the episode files of the library.
+package nfo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/library"
+	"bereths.com/netstar/themoviedb"
+)
+
+// what the exporter did or would do with a file
+type Action struct {
+	Path   string
+	Write  bool
+	Reason string
+}
+
+type Exporter struct {
+	client       *themoviedb.Client
+	http         *http.Client
+	imageBaseURL string
+
+	// only report what would be written
+	DryRun bool
+
+	// files planned in this export, so a dry run does not plan the same file twice
+	planned map[string]bool
+}
+
+func NewExporter(client *themoviedb.Client, httpClient *http.Client) *Exporter {
+	return &Exporter{client: client, http: httpClient, imageBaseURL: themoviedb.ImageBaseURL}
+}
+
+// writes the nfo files and artwork of all files. hand made or hand edited nfo
+// files and existing images are never overwritten
+func (e *Exporter) Export(files []library.File) ([]Action, error) {
+	var actions []Action
+	e.planned = map[string]bool{}
+	shows := map[int]*themoviedb.TVShowDetails{}
+
+	for _, f := range files {
+		show, ok := shows[f.ShowID]
+		if !ok {
+			var err error
+			show, err = e.client.GetTVShowDetails(strconv.Itoa(f.ShowID))
+			if err != nil {
+				return actions, err
+			}
+			shows[f.ShowID] = show
+		}
+
+		dir := library.ShowDir(f.Path)
+		showActions, err := e.exportShow(dir, show)
+		actions = append(actions, showActions...)
+		if err != nil {
+			return actions, err
+		}
+
+		episodeActions, err := e.exportEpisode(f, show)
+		actions = append(actions, episodeActions...)
+		if err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+func (e *Exporter) exportShow(dir string, show *themoviedb.TVShowDetails) ([]Action, error) {
+	path := filepath.Join(dir, "tvshow.nfo")
+	if e.planned[path] {
+		return nil, nil
+	}
+
+	var actions []Action
+	action, err := e.writeNFO(path, NewTVShow(show))
+	actions = append(actions, action)
+	if err != nil {
+		return actions, err
+	}
+
+	for name, image := range map[string]string{"poster.jpg": show.PosterPath, "fanart.jpg": show.BackdropPath} {
+		if image == "" {
+			continue
+		}
+		action, err := e.writeImage(filepath.Join(dir, name), image)
+		actions = append(actions, action)
+		if err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+func (e *Exporter) exportEpisode(f library.File, show *themoviedb.TVShowDetails) ([]Action, error) {
+	base := strings.TrimSuffix(f.Path, filepath.Ext(f.Path))
+	season := strconv.Itoa(f.Season)
+
+	// files with more than one episode get one <episodedetails> per episode
+	var episodes []interface{}
+	still := ""
+	for _, number := range f.Episodes {
+		episode, err := e.client.GetEpisodeDetails(strconv.Itoa(f.ShowID), season, strconv.Itoa(number.Number))
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, NewEpisode(show, episode))
+		if still == "" {
+			still = episode.StillPath
+		}
+	}
+
+	var actions []Action
+	action, err := e.writeNFO(base+".nfo", episodes...)
+	actions = append(actions, action)
+	if err != nil || still == "" {
+		return actions, err
+	}
+
+	action, err = e.writeImage(base+"-thumb.jpg", still)
+	return append(actions, action), err
+}
+
+// the first line of every nfo written by netstar, holding the checksum of the rest of the file
+var marker = regexp.MustCompile(`^<!-- created by netstar, sha256:([0-9a-f]{64})\. remove this line to keep netstar from updating this file -->\n`)
+
+func markerFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("<!-- created by netstar, sha256:%s. remove this line to keep netstar from updating this file -->\n", hex.EncodeToString(sum[:]))
+}
+
+func (e *Exporter) writeNFO(path string, documents ...interface{}) (Action, error) {
+	e.planned[path] = true
+
+	body := &bytes.Buffer{}
+	for _, doc := range documents {
+		b, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return Action{Path: path}, err
+		}
+		body.Write(b)
+		body.WriteString("\n")
+	}
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return Action{Path: path}, err
+	default:
+		if reason := keepReason(existing, body.Bytes()); reason != "" {
+			return Action{Path: path, Reason: reason}, nil
+		}
+	}
+
+	action := Action{Path: path, Write: true, Reason: "created"}
+	if existing != nil {
+		action.Reason = "updated"
+	}
+	if e.DryRun {
+		return action, nil
+	}
+
+	content := xml.Header + markerFor(body.Bytes()) + body.String()
+	return action, os.WriteFile(path, []byte(content), 0644)
+}
+
+// tells why an existing nfo must not be replaced with body, or "" if it can be
+func keepReason(existing, body []byte) string {
+	existing = bytes.TrimPrefix(existing, []byte(xml.Header))
+	m := marker.FindSubmatch(existing)
+	if m == nil {
+		return "not created by netstar"
+	}
+
+	rest := existing[len(m[0]):]
+	sum := sha256.Sum256(rest)
+	if hex.EncodeToString(sum[:]) != string(m[1]) {
+		return "edited by hand"
+	}
+	if bytes.Equal(rest, body) {
+		return "unchanged"
+	}
+	return ""
+}
+
+func (e *Exporter) writeImage(path, imagePath string) (Action, error) {
+	e.planned[path] = true
+
+	if _, err := os.Stat(path); err == nil {
+		return Action{Path: path, Reason: "exists"}, nil
+	}
+
+	action := Action{Path: path, Write: true, Reason: "created"}
+	if e.DryRun {
+		return action, nil
+	}
+
+	resp, err := e.http.Get(e.imageBaseURL + "/original" + imagePath)
+	if err != nil {
+		return action, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return action, fmt.Errorf("could not download %s: %s", imagePath, resp.Status)
+	}
+
+	// download to a temporary file so a broken download never looks like an existing image
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return action, err
+	}
+	_, err = io.Copy(out, resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return action, err
+	}
+	return action, os.Rename(tmp, path)
+}
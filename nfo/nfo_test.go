@@ -0,0 +1,161 @@
+package nfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/library"
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+// creates an exporter talking to a fake themoviedb api and image host
+func GetMockExporter(t *testing.T, overview *string) *Exporter {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","poster_path":"/poster.jpg","overview":"` + *overview + `"}`))
+		case "/tv/70523/season/1/episode/1":
+			w.Write([]byte(`{"id":1,"name":"Secrets","season_number":1,"episode_number":1,"still_path":"/still.jpg"}`))
+		case "/tv/70523/season/1/episode/2":
+			w.Write([]byte(`{"id":2,"name":"Lies","season_number":1,"episode_number":2}`))
+		case "/images/original/poster.jpg", "/images/original/still.jpg":
+			w.Write([]byte("image"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	client := themoviedb.NewClient(httpClient, "1234", "en-US", false)
+	client.SetBaseURL(mockServer.URL)
+
+	exporter := NewExporter(client, httpClient)
+	exporter.imageBaseURL = mockServer.URL + "/images"
+	return exporter
+}
+
+// the library files of a show in dir. the media files are created as well
+func GetMockFiles(dir string) []library.File {
+	files := []library.File{
+		{Path: filepath.Join(dir, "Season 1", "Dark.S01E01.mkv"), ShowID: 70523, Season: 1, Episodes: []library.Episode{{Number: 1}}},
+		{Path: filepath.Join(dir, "Season 1", "Dark.S01E02.mkv"), ShowID: 70523, Season: 1, Episodes: []library.Episode{{Number: 2}}},
+	}
+	for _, f := range files {
+		os.MkdirAll(filepath.Dir(f.Path), 0755)
+		os.WriteFile(f.Path, []byte("video"), 0644)
+	}
+	return files
+}
+
+func Written(actions []Action) []string {
+	var written []string
+	for _, a := range actions {
+		if a.Write {
+			written = append(written, filepath.Base(a.Path))
+		}
+	}
+	return written
+}
+
+func TestExport(t *testing.T) {
+
+	dir := t.TempDir()
+	overview := "A missing child"
+	exporter := GetMockExporter(t, &overview)
+
+	actions, err := exporter.Export(GetMockFiles(dir))
+
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"tvshow.nfo", "poster.jpg", "Dark.S01E01.nfo", "Dark.S01E01-thumb.jpg", "Dark.S01E02.nfo"}, Written(actions))
+
+	show, _ := os.ReadFile(filepath.Join(dir, "tvshow.nfo"))
+	assert.Contains(t, string(show), "<title>Dark</title>")
+	assert.Contains(t, string(show), `<uniqueid type="tmdb" default="true">70523</uniqueid>`)
+
+	episode, _ := os.ReadFile(filepath.Join(dir, "Season 1", "Dark.S01E02.nfo"))
+	assert.Contains(t, string(episode), "<episodedetails>")
+	assert.Contains(t, string(episode), "<showtitle>Dark</showtitle>")
+
+	poster, _ := os.ReadFile(filepath.Join(dir, "poster.jpg"))
+	assert.Equal(t, "image", string(poster))
+
+	// nothing changed upstream, so nothing is written again
+	actions, err = exporter.Export(GetMockFiles(dir))
+
+	assert.Nil(t, err)
+	assert.Empty(t, Written(actions))
+}
+
+func TestExportDryRun(t *testing.T) {
+
+	dir := t.TempDir()
+	overview := "A missing child"
+	exporter := GetMockExporter(t, &overview)
+	exporter.DryRun = true
+
+	actions, err := exporter.Export(GetMockFiles(dir))
+
+	assert.Nil(t, err)
+	assert.Len(t, Written(actions), 5)
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "Season 1"))
+	assert.Len(t, entries, 2, "dry run should not write anything")
+	_, err = os.Stat(filepath.Join(dir, "tvshow.nfo"))
+	assert.True(t, os.IsNotExist(err), "dry run should not write anything")
+}
+
+func TestExportKeepsHandEditedFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	overview := "A missing child"
+	exporter := GetMockExporter(t, &overview)
+
+	_, err := exporter.Export(GetMockFiles(dir))
+	assert.Nil(t, err)
+
+	// edit one generated file and replace another one with a hand made one
+	showPath := filepath.Join(dir, "tvshow.nfo")
+	show, _ := os.ReadFile(showPath)
+	os.WriteFile(showPath, []byte(strings.Replace(string(show), "A missing child", "My own plot", 1)), 0644)
+
+	episodePath := filepath.Join(dir, "Season 1", "Dark.S01E01.nfo")
+	os.WriteFile(episodePath, []byte("<episodedetails><title>Mine</title></episodedetails>"), 0644)
+
+	// a changed overview upstream would update both files
+	overview = "Updated overview"
+	actions, err := exporter.Export(GetMockFiles(dir))
+
+	assert.Nil(t, err)
+	assert.Empty(t, Written(actions))
+
+	show, _ = os.ReadFile(showPath)
+	assert.Contains(t, string(show), "My own plot")
+	episode, _ := os.ReadFile(episodePath)
+	assert.Equal(t, "<episodedetails><title>Mine</title></episodedetails>", string(episode))
+}
+
+func TestExportUpdatesGeneratedFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	overview := "A missing child"
+	exporter := GetMockExporter(t, &overview)
+
+	_, err := exporter.Export(GetMockFiles(dir))
+	assert.Nil(t, err)
+
+	overview = "Updated overview"
+	actions, err := exporter.Export(GetMockFiles(dir))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tvshow.nfo"}, Written(actions))
+
+	show, _ := os.ReadFile(filepath.Join(dir, "tvshow.nfo"))
+	assert.Contains(t, string(show), "Updated overview")
+}
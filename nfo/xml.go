@@ -0,0 +1,142 @@
+package nfo
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// the elements follow https://kodi.wiki/view/NFO_files/TV_shows and
+// https://kodi.wiki/view/NFO_files/Episodes which Jellyfin reads as well
+
+type UniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type Rating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr,omitempty"`
+	Value   float64 `xml:"value"`
+	Votes   int     `xml:"votes"`
+}
+
+type Thumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type Actor struct {
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Order int    `xml:"order"`
+	Thumb string `xml:"thumb,omitempty"`
+}
+
+type TVShow struct {
+	XMLName       xml.Name   `xml:"tvshow"`
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle,omitempty"`
+	Ratings       []Rating   `xml:"ratings>rating"`
+	Plot          string     `xml:"plot"`
+	Tagline       string     `xml:"tagline,omitempty"`
+	Thumbs        []Thumb    `xml:"thumb"`
+	Fanart        []Thumb    `xml:"fanart>thumb,omitempty"`
+	UniqueIDs     []UniqueID `xml:"uniqueid"`
+	Genres        []string   `xml:"genre"`
+	Premiered     string     `xml:"premiered,omitempty"`
+	Status        string     `xml:"status,omitempty"`
+	Studios       []string   `xml:"studio"`
+	Credits       []string   `xml:"credits"`
+}
+
+type Episode struct {
+	XMLName   xml.Name   `xml:"episodedetails"`
+	Title     string     `xml:"title"`
+	ShowTitle string     `xml:"showtitle"`
+	Ratings   []Rating   `xml:"ratings>rating"`
+	Season    int        `xml:"season"`
+	Episode   int        `xml:"episode"`
+	Plot      string     `xml:"plot"`
+	Thumbs    []Thumb    `xml:"thumb"`
+	UniqueIDs []UniqueID `xml:"uniqueid"`
+	Aired     string     `xml:"aired,omitempty"`
+	Credits   []string   `xml:"credits"`
+	Directors []string   `xml:"director"`
+	Actors    []Actor    `xml:"actor"`
+}
+
+func imageURL(path string) string {
+	return themoviedb.ImageBaseURL + "/original" + path
+}
+
+func NewTVShow(show *themoviedb.TVShowDetails) *TVShow {
+	nfo := &TVShow{
+		Title:         show.Name,
+		OriginalTitle: show.OriginalName,
+		Ratings:       []Rating{{Name: "themoviedb", Max: 10, Default: true, Value: show.VoteAverage, Votes: show.VoteCount}},
+		Plot:          show.Overview,
+		Tagline:       show.Tagline,
+		UniqueIDs:     []UniqueID{{Type: "tmdb", Default: true, Value: strconv.Itoa(show.ID)}},
+		Premiered:     show.FirstAirDate,
+		Status:        show.Status,
+	}
+
+	if show.PosterPath != "" {
+		nfo.Thumbs = append(nfo.Thumbs, Thumb{Aspect: "poster", Value: imageURL(show.PosterPath)})
+	}
+	if show.BackdropPath != "" {
+		nfo.Fanart = append(nfo.Fanart, Thumb{Value: imageURL(show.BackdropPath)})
+	}
+	for _, season := range show.Seasons {
+		if season.PosterPath != "" {
+			nfo.Thumbs = append(nfo.Thumbs, Thumb{Aspect: "poster", Value: imageURL(season.PosterPath)})
+		}
+	}
+	for _, genre := range show.Genres {
+		nfo.Genres = append(nfo.Genres, genre.Name)
+	}
+	for _, network := range show.Networks {
+		nfo.Studios = append(nfo.Studios, network.Name)
+	}
+	for _, creator := range show.CreatedBy {
+		nfo.Credits = append(nfo.Credits, creator.Name)
+	}
+	return nfo
+}
+
+func NewEpisode(show *themoviedb.TVShowDetails, episode *themoviedb.TVEpisodeDetails) *Episode {
+	nfo := &Episode{
+		Title:     episode.Name,
+		ShowTitle: show.Name,
+		Ratings:   []Rating{{Name: "themoviedb", Max: 10, Default: true, Value: episode.VoteAverage, Votes: episode.VoteCount}},
+		Season:    episode.SeasonNumber,
+		Episode:   episode.EpisodeNumber,
+		Plot:      episode.Overview,
+		UniqueIDs: []UniqueID{{Type: "tmdb", Default: true, Value: strconv.Itoa(episode.ID)}},
+		Aired:     episode.AirDate,
+	}
+
+	if episode.StillPath != "" {
+		nfo.Thumbs = append(nfo.Thumbs, Thumb{Value: imageURL(episode.StillPath)})
+	}
+	for _, member := range episode.Crew {
+		switch member.Job {
+		case "Director":
+			nfo.Directors = append(nfo.Directors, member.Name)
+		case "Writer", "Screenplay", "Teleplay":
+			nfo.Credits = append(nfo.Credits, member.Name)
+		}
+	}
+	for _, star := range episode.GuestStars {
+		actor := Actor{Name: star.Name, Role: star.Character, Order: star.Order}
+		if star.ProfilePath != "" {
+			actor.Thumb = imageURL(star.ProfilePath)
+		}
+		nfo.Actors = append(nfo.Actors, actor)
+	}
+	return nfo
+}
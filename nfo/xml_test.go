@@ -0,0 +1,49 @@
+package nfo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEpisode(t *testing.T) {
+
+	show := &themoviedb.TVShowDetails{Name: "Dark"}
+	episode := &themoviedb.TVEpisodeDetails{}
+	json.Unmarshal([]byte(`{
+		"id": 1, "name": "Secrets", "season_number": 1, "episode_number": 1, "air_date": "2017-12-01",
+		"crew": [{"name": "Baran bo Odar", "job": "Director"}, {"name": "Jantje Friese", "job": "Writer"}, {"name": "Someone", "job": "Editor"}],
+		"guest_stars": [{"name": "Louis Hofmann", "character": "Jonas Kahnwald", "order": 0}]
+	}`), episode)
+
+	nfo := NewEpisode(show, episode)
+
+	assert.Equal(t, []string{"Baran bo Odar"}, nfo.Directors)
+	assert.Equal(t, []string{"Jantje Friese"}, nfo.Credits)
+	assert.Equal(t, []Actor{{Name: "Louis Hofmann", Role: "Jonas Kahnwald"}}, nfo.Actors)
+
+	b, err := xml.Marshal(nfo)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "<aired>2017-12-01</aired>")
+	assert.Contains(t, string(b), "<season>1</season><episode>1</episode>")
+}
+
+func TestNewTVShow(t *testing.T) {
+
+	show := &themoviedb.TVShowDetails{}
+	json.Unmarshal([]byte(`{
+		"id": 70523, "name": "Dark", "poster_path": "/poster.jpg", "backdrop_path": "/backdrop.jpg",
+		"genres": [{"name": "Drama"}], "networks": [{"name": "Netflix"}]
+	}`), show)
+
+	b, err := xml.Marshal(NewTVShow(show))
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `<thumb aspect="poster">https://image.tmdb.org/t/p/original/poster.jpg</thumb>`)
+	assert.Contains(t, string(b), `<fanart><thumb>https://image.tmdb.org/t/p/original/backdrop.jpg</thumb></fanart>`)
+	assert.Contains(t, string(b), "<genre>Drama</genre>")
+	assert.Contains(t, string(b), "<studio>Netflix</studio>")
+}
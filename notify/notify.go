@@ -0,0 +1,110 @@
+// Package notify watches the tracked shows for new episodes and status changes
+// and tells configured webhooks about them.
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// the kinds of events sent to webhooks
+const (
+	EpisodeAired  = "episode.aired"
+	SeasonPremier = "season.premiere"
+	StatusChanged = "show.status_changed"
+)
+
+// what netstar remembers about a show to notice changes
+type Snapshot struct {
+	ShowID      int     `json:"show_id"`
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	LastEpisode Episode `json:"last_episode"`
+}
+
+type Episode struct {
+	Season  int    `json:"season"`
+	Number  int    `json:"episode"`
+	Name    string `json:"name,omitempty"`
+	AirDate string `json:"air_date,omitempty"`
+}
+
+func (e Episode) after(o Episode) bool {
+	return e.Season > o.Season || e.Season == o.Season && e.Number > o.Number
+}
+
+func NewSnapshot(show *themoviedb.TVShowDetails) *Snapshot {
+	last := show.LastEpisodeToAir
+	return &Snapshot{
+		ShowID:      show.ID,
+		Name:        show.Name,
+		Status:      show.Status,
+		LastEpisode: Episode{last.SeasonNumber, last.EpisodeNumber, last.Name, last.AirDate},
+	}
+}
+
+// the json payload of a webhook
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Show      Show      `json:"show"`
+	Episode   *Episode  `json:"episode,omitempty"`
+	OldStatus string    `json:"old_status,omitempty"`
+	Status    string    `json:"status,omitempty"`
+}
+
+type Show struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// the events between two snapshots of a show. episodes are the episodes of the seasons
+// since the old snapshot; every one aired after the old last episode up to the current one is
+// a new episode, the first episode of a later season is a premiere. without episodes only
+// the current last episode is told about
+func Detect(old, current *Snapshot, episodes []Episode, now time.Time) []Event {
+	var events []Event
+	show := Show{current.ShowID, current.Name}
+
+	var aired []Episode
+	for _, ep := range episodes {
+		if ep.after(old.LastEpisode) && !ep.after(current.LastEpisode) {
+			aired = append(aired, ep)
+		}
+	}
+	sort.Slice(aired, func(i, j int) bool { return aired[j].after(aired[i]) })
+	if len(aired) == 0 && current.LastEpisode.after(old.LastEpisode) {
+		aired = []Episode{current.LastEpisode}
+	}
+
+	for _, ep := range aired {
+		ep := ep
+		kind := EpisodeAired
+		if ep.Number == 1 && ep.Season > old.LastEpisode.Season {
+			kind = SeasonPremier
+		}
+		events = append(events, Event{
+			ID:      fmt.Sprintf("%s-%d-s%de%d", kind, show.ID, ep.Season, ep.Number),
+			Type:    kind,
+			Time:    now,
+			Show:    show,
+			Episode: &ep,
+		})
+	}
+
+	if old.Status != "" && current.Status != old.Status {
+		events = append(events, Event{
+			ID:        fmt.Sprintf("%s-%d-%d", StatusChanged, show.ID, now.Unix()),
+			Type:      StatusChanged,
+			Time:      now,
+			Show:      show,
+			OldStatus: old.Status,
+			Status:    current.Status,
+		})
+	}
+	return events
+}
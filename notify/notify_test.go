@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+
+	now := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	old := &Snapshot{ShowID: 70523, Name: "Dark", Status: "Returning Series", LastEpisode: Episode{Season: 2, Number: 8}}
+
+	events := Detect(old, old, nil, now)
+	assert.Empty(t, events, "nothing changed")
+
+	events = Detect(old, &Snapshot{ShowID: 70523, Name: "Dark", Status: "Returning Series", LastEpisode: Episode{Season: 3, Number: 1, Name: "Deja-vu"}}, nil, now)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, SeasonPremier, events[0].Type)
+		assert.Equal(t, "season.premiere-70523-s3e1", events[0].ID)
+		assert.Equal(t, "Deja-vu", events[0].Episode.Name)
+	}
+
+	events = Detect(old, &Snapshot{ShowID: 70523, Name: "Dark", Status: "Ended", LastEpisode: Episode{Season: 2, Number: 9}}, nil, now)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, EpisodeAired, events[0].Type)
+		assert.Equal(t, StatusChanged, events[1].Type)
+		assert.Equal(t, "Returning Series", events[1].OldStatus)
+		assert.Equal(t, "Ended", events[1].Status)
+	}
+}
+
+func TestDetectEveryEpisodeSinceTheLastCheck(t *testing.T) {
+
+	now := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	old := &Snapshot{ShowID: 70523, Name: "Dark", Status: "Returning Series", LastEpisode: Episode{Season: 2, Number: 8}}
+	current := &Snapshot{ShowID: 70523, Name: "Dark", Status: "Returning Series", LastEpisode: Episode{Season: 3, Number: 3}}
+	episodes := []Episode{{Season: 3, Number: 2}, {Season: 3, Number: 1}, {Season: 3, Number: 4}, {Season: 3, Number: 3},
+		{Season: 2, Number: 7}, {Season: 2, Number: 8}, {Season: 2, Number: 9}}
+
+	var ids []string
+	for _, event := range Detect(old, current, episodes, now) {
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{
+		"episode.aired-70523-s2e9",
+		"season.premiere-70523-s3e1",
+		"episode.aired-70523-s3e2",
+		"episode.aired-70523-s3e3",
+	}, ids, "a season released at once should be told about episode by episode")
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// the shows to keep an eye on, like the shows on any watchlist
+type ShowLister interface {
+	Shows() ([]int, error)
+}
+
+// refreshes the tracked shows every Interval and sends what changed
+type Scheduler struct {
+	TMDB     *themoviedb.Client
+	Shows    ShowLister
+	Store    *Store
+	Sender   *Sender
+	Interval time.Duration
+}
+
+// how often failed deliveries are looked at, they are only tried again once their wait is over
+const retryInterval = 10 * time.Second
+
+// checks the shows right away and then every Interval until ctx is done,
+// in between failed deliveries are tried again
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	retries := time.NewTicker(retryInterval)
+	defer retries.Stop()
+
+	check := true
+	for {
+		if check {
+			if err := s.Check(); err != nil {
+				log.Printf("Could not check tracked shows: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check = true
+		case now := <-retries.C:
+			check = false
+			if err := s.Sender.Retry(now); err != nil {
+				log.Printf("Could not retry webhook deliveries: %v", err)
+			}
+		}
+	}
+}
+
+// refreshes every tracked show once. a show seen for the first time only gets a snapshot,
+// so starting netstar does not flood the webhooks
+func (s *Scheduler) Check() error {
+	ids, err := s.Shows.Shows()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		show, err := s.TMDB.GetTVShowDetails(strconv.Itoa(id))
+		if err != nil {
+			log.Printf("Could not refresh tracked show %d: %v", id, err)
+			continue
+		}
+
+		current := NewSnapshot(show)
+		old, err := s.Store.Snapshot(id)
+		if err != nil {
+			return err
+		}
+
+		if old != nil {
+			var episodes []Episode
+			if current.LastEpisode.after(old.LastEpisode) {
+				episodes = s.episodesSince(id, old.LastEpisode.Season, current.LastEpisode.Season)
+			}
+			for _, event := range Detect(old, current, episodes, time.Now().UTC()) {
+				log.Printf("Sending %s", event.ID)
+				if err := s.Sender.Send(event); err != nil {
+					return err
+				}
+			}
+		}
+
+		// saved after sending, so events are not lost if netstar stops in between
+		if err := s.Store.PutSnapshot(current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the episodes of the seasons from to to. nil if a season could not be loaded,
+// then only the latest episode is told about
+func (s *Scheduler) episodesSince(id, from, to int) []Episode {
+	if from < 1 {
+		from = 1
+	}
+	var episodes []Episode
+	for season := from; season <= to; season++ {
+		details, err := s.TMDB.GetSeasonDetails(strconv.Itoa(id), strconv.Itoa(season))
+		if err != nil {
+			log.Printf("Could not load season %d of tracked show %d: %v", season, id, err)
+			return nil
+		}
+		for _, e := range details.Episodes {
+			episodes = append(episodes, Episode{season, e.EpisodeNumber, e.Name, e.AirDate})
+		}
+	}
+	return episodes
+}
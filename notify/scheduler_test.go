@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockShows []int
+
+func (m mockShows) Shows() ([]int, error) {
+	return m, nil
+}
+
+func TestSchedulerCheck(t *testing.T) {
+
+	show := `{"id":70523,"name":"Dark","status":"Returning Series","last_episode_to_air":{"season_number":2,"episode_number":8}}`
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(show))
+		case "/tv/70523/season/3":
+			w.Write([]byte(`{"season_number":3,"episodes":[{"episode_number":1},{"episode_number":2},{"episode_number":3},{"episode_number":4}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer tmdbServer.Close()
+	client := themoviedb.NewClient(&http.Client{Timeout: time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(tmdbServer.URL)
+
+	var events []Event
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+	}))
+	defer webhook.Close()
+
+	store := GetTestStore(t)
+	scheduler := &Scheduler{TMDB: client, Shows: mockShows{70523, 404}, Store: store, Sender: NewSender([]string{webhook.URL}, "secret", store)}
+
+	assert.Nil(t, scheduler.Check())
+	assert.Empty(t, events, "first check should only remember the show")
+
+	assert.Nil(t, scheduler.Check())
+	assert.Empty(t, events, "nothing changed")
+
+	show = `{"id":70523,"name":"Dark","status":"Ended","last_episode_to_air":{"season_number":3,"episode_number":1}}`
+	assert.Nil(t, scheduler.Check())
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, SeasonPremier, events[0].Type)
+		assert.Equal(t, StatusChanged, events[1].Type)
+	}
+
+	assert.Nil(t, scheduler.Check())
+	assert.Len(t, events, 2, "events should be sent once")
+
+	show = `{"id":70523,"name":"Dark","status":"Ended","last_episode_to_air":{"season_number":3,"episode_number":3}}`
+	assert.Nil(t, scheduler.Check())
+	if assert.Len(t, events, 4, "both episodes aired since the last check should be sent") {
+		assert.Equal(t, "episode.aired-70523-s3e2", events[2].ID)
+		assert.Equal(t, "episode.aired-70523-s3e3", events[3].ID)
+	}
+}
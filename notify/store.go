@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	snapshotsBucket  = []byte("show_snapshots")
+	deliveriesBucket = []byte("webhook_deliveries")
+)
+
+// how many deliveries the log keeps
+const maxDeliveries = 1000
+
+// an attempt to deliver an event to a webhook
+type Delivery struct {
+	ID         int       `json:"id"`
+	EventID    string    `json:"event_id"`
+	Event      string    `json:"event"`
+	URL        string    `json:"url"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Delivered  bool      `json:"delivered"`
+	Time       time.Time `json:"time"`
+}
+
+// the snapshots of the tracked shows and the delivery log, in the bolt database
+type Store struct {
+	db *bolt.DB
+}
+
+func NewStore(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{snapshotsBucket, deliveriesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// the last snapshot of the show or nil if it was never checked
+func (s *Store) Snapshot(showID int) (*Snapshot, error) {
+	var snapshot *Snapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(snapshotsBucket).Get(itob(showID))
+		if b == nil {
+			return nil
+		}
+		snapshot = &Snapshot{}
+		return json.Unmarshal(b, snapshot)
+	})
+	return snapshot, err
+}
+
+func (s *Store) PutSnapshot(snapshot *Snapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(snapshotsBucket).Put(itob(snapshot.ShowID), b)
+	})
+}
+
+// adds the delivery to the log and drops the oldest ones. a delivery that was logged
+// before, like after a retry, is updated instead
+func (s *Store) LogDelivery(d *Delivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deliveriesBucket)
+		if d.ID != 0 {
+			b, err := json.Marshal(d)
+			if err != nil {
+				return err
+			}
+			return bucket.Put(itob(d.ID), b)
+		}
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		d.ID = int(id)
+
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(itob(d.ID), b); err != nil {
+			return err
+		}
+
+		// ids are sequential, so everything older than the last maxDeliveries goes
+		var old [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil && int(binary.BigEndian.Uint64(k)) <= d.ID-maxDeliveries; k, _ = c.Next() {
+			old = append(old, k)
+		}
+		for _, k := range old {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// the latest deliveries first
+func (s *Store) Deliveries(limit int) ([]Delivery, error) {
+	deliveries := []Delivery{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(deliveriesBucket).Cursor()
+		for k, v := c.Last(); k != nil && len(deliveries) < limit; k, v = c.Prev() {
+			var d Delivery
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, d)
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
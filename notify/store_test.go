@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSnapshots(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	snapshot, err := store.Snapshot(70523)
+	assert.Nil(t, err)
+	assert.Nil(t, snapshot, "show was never checked")
+
+	store.PutSnapshot(&Snapshot{ShowID: 70523, Name: "Dark", Status: "Ended"})
+	snapshot, _ = store.Snapshot(70523)
+	assert.Equal(t, "Ended", snapshot.Status)
+}
+
+func TestDeliveryLog(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	for i := 0; i < maxDeliveries+5; i++ {
+		assert.Nil(t, store.LogDelivery(&Delivery{EventID: "event", URL: "http://localhost"}))
+	}
+
+	deliveries, err := store.Deliveries(maxDeliveries * 2)
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, maxDeliveries, "oldest deliveries should be dropped")
+	assert.Equal(t, maxDeliveries+5, deliveries[0].ID, "latest delivery should come first")
+
+	deliveries, _ = store.Deliveries(3)
+	assert.Len(t, deliveries, 3)
+}
@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// the hmac of the body, like sha256=5d41...
+	SignatureHeader = "X-Netstar-Signature"
+	EventHeader     = "X-Netstar-Event"
+	DeliveryHeader  = "X-Netstar-Delivery"
+)
+
+// sends events to the webhook urls. failed deliveries are queued and tried again by Retry,
+// so a dead webhook does not hold up the others
+type Sender struct {
+	URLs []string
+	// the key the payloads are signed with, receivers verify the signature header with it
+	Secret string
+	Client *http.Client
+	Store  *Store
+
+	// how often a delivery is tried and how long to wait before the first retry,
+	// the wait doubles with every attempt
+	Attempts int
+	Backoff  time.Duration
+
+	mu      sync.Mutex
+	retries []*retry
+}
+
+// a failed delivery waiting to be tried again
+type retry struct {
+	delivery *Delivery
+	event    Event
+	body     []byte
+	next     time.Time
+	wait     time.Duration
+}
+
+func NewSender(urls []string, secret string, store *Store) *Sender {
+	return &Sender{
+		URLs:     urls,
+		Secret:   secret,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Store:    store,
+		Attempts: 5,
+		Backoff:  30 * time.Second,
+	}
+}
+
+// the signature of body as sent in the SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// tries to deliver the event to every url once and queues the failed deliveries
+func (s *Sender) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range s.URLs {
+		d := &Delivery{EventID: event.ID, Event: event.Type, URL: url}
+		if err := s.attempt(&retry{delivery: d, event: event, body: body, wait: s.Backoff}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tries the queued deliveries that are due at now once more
+func (s *Sender) Retry(now time.Time) error {
+	s.mu.Lock()
+	var due, waiting []*retry
+	for _, r := range s.retries {
+		if r.next.After(now) {
+			waiting = append(waiting, r)
+		} else {
+			due = append(due, r)
+		}
+	}
+	s.retries = waiting
+	s.mu.Unlock()
+
+	for _, r := range due {
+		if err := s.attempt(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// posts the delivery once, logs how it went and queues it again if it failed and another try may help
+func (s *Sender) attempt(r *retry) error {
+	d := r.delivery
+	d.Attempts++
+	d.Time = time.Now().UTC()
+
+	status, err := s.post(d.URL, r.event, r.body)
+	d.StatusCode = status
+	if err == nil {
+		d.Error = ""
+		d.Delivered = true
+	} else {
+		d.Error = err.Error()
+		// the receiver does not want it, trying again will not help
+		rejected := status >= 400 && status < 500 && status != http.StatusTooManyRequests && status != http.StatusRequestTimeout
+		if d.Attempts < s.Attempts && !rejected {
+			r.next = d.Time.Add(r.wait)
+			r.wait *= 2
+			s.mu.Lock()
+			s.retries = append(s.retries, r)
+			s.mu.Unlock()
+		} else {
+			log.Printf("Could not deliver %s to %s: %s", d.EventID, d.URL, d.Error)
+		}
+	}
+
+	if s.Store != nil {
+		return s.Store.LogDelivery(d)
+	}
+	return nil
+}
+
+func (s *Sender) post(url string, event Event, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "netstar-webhook")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, body))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered with %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// answers with the statuses one after another
+func GetMockWebhook(t *testing.T, statuses ...int) (*httptest.Server, *[]*http.Request, *[][]byte) {
+	var requests []*http.Request
+	var bodies [][]byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(statuses[len(requests)-1])
+	}))
+	t.Cleanup(mockServer.Close)
+	return mockServer, &requests, &bodies
+}
+
+func GetTestSender(t *testing.T, url string) *Sender {
+	sender := NewSender([]string{url}, "secret", GetTestStore(t))
+	sender.Backoff = time.Millisecond
+	sender.Attempts = 3
+	return sender
+}
+
+func TestSendSignsPayload(t *testing.T) {
+
+	webhook, requests, bodies := GetMockWebhook(t, http.StatusOK)
+	sender := GetTestSender(t, webhook.URL)
+
+	assert.Nil(t, sender.Send(Event{ID: "episode.aired-1-s1e2", Type: EpisodeAired, Show: Show{1, "Dark"}}))
+
+	if assert.Len(t, *requests, 1) {
+		r := (*requests)[0]
+		assert.Equal(t, Sign("secret", (*bodies)[0]), r.Header.Get(SignatureHeader))
+		assert.Equal(t, EpisodeAired, r.Header.Get(EventHeader))
+		assert.Equal(t, "episode.aired-1-s1e2", r.Header.Get(DeliveryHeader))
+		assert.Contains(t, string((*bodies)[0]), `"name":"Dark"`)
+	}
+
+	deliveries, _ := sender.Store.Deliveries(10)
+	assert.True(t, deliveries[0].Delivered)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+}
+
+func TestSendRetries(t *testing.T) {
+
+	webhook, requests, _ := GetMockWebhook(t, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusNoContent)
+	sender := GetTestSender(t, webhook.URL)
+
+	sender.Send(Event{ID: "event", Type: EpisodeAired})
+	assert.Len(t, *requests, 1, "retries should not hold up sending")
+	assert.Len(t, sender.retries, 1)
+
+	assert.Nil(t, sender.Retry(time.Now().Add(-time.Hour)))
+	assert.Len(t, *requests, 1, "the retry is not due yet")
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, sender.Retry(time.Now().Add(time.Hour)))
+	}
+
+	assert.Len(t, *requests, 3)
+	assert.Empty(t, sender.retries)
+	deliveries, _ := sender.Store.Deliveries(10)
+	if assert.Len(t, deliveries, 1, "retries should update the logged delivery") {
+		assert.True(t, deliveries[0].Delivered)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusNoContent, deliveries[0].StatusCode)
+	}
+}
+
+func TestSendStopsRetrying(t *testing.T) {
+
+	webhook, requests, _ := GetMockWebhook(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	sender := GetTestSender(t, webhook.URL)
+
+	sender.Send(Event{ID: "event", Type: EpisodeAired})
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, sender.Retry(time.Now().Add(time.Hour)))
+	}
+
+	assert.Len(t, *requests, 3, "a delivery should be tried Attempts times")
+	assert.Empty(t, sender.retries)
+	deliveries, _ := sender.Store.Deliveries(10)
+	assert.False(t, deliveries[0].Delivered)
+}
+
+func TestSendGivesUp(t *testing.T) {
+
+	webhook, requests, _ := GetMockWebhook(t, http.StatusGone)
+	sender := GetTestSender(t, webhook.URL)
+
+	sender.Send(Event{ID: "event", Type: EpisodeAired})
+
+	assert.Len(t, *requests, 1, "client errors should not be retried")
+	assert.Empty(t, sender.retries)
+	deliveries, _ := sender.Store.Deliveries(10)
+	assert.False(t, deliveries[0].Delivered)
+	assert.Equal(t, "webhook answered with 410 Gone", deliveries[0].Error)
+}
+
+func TestSign(t *testing.T) {
+
+	assert.Equal(t, "sha256=5d98b45c90a207fa998ce639fea6f02ecc8cc3f36fef81d694fb856b4d0a28ca", Sign("key", []byte("payload")))
+}
@@ -0,0 +1,311 @@
+// Package organize renames and moves episode files into a folder layout built
+// from their themoviedb metadata. every move is written to a journal so a run
+// can be undone.
+package organize
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bereths.com/netstar/library"
+)
+
+// the name of the journal written to the target directory
+const JournalName = ".netstar-organize.jsonl"
+
+// subtitles moved together with their episode, e.g. Dark.S01E01.en.srt
+var sidecarExtensions = map[string]bool{".srt": true, ".sub": true, ".idx": true, ".ass": true, ".ssa": true, ".vtt": true}
+
+type Move struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// why the move can not be done, empty if it can
+	Conflict string `json:"-"`
+	// the run the move belongs to in the journal
+	Batch string `json:"batch,omitempty"`
+}
+
+type Plan struct {
+	Dir       string
+	Moves     []Move
+	Organized int
+	Unmatched []library.Unmatched
+}
+
+// the moves without conflicts
+func (p *Plan) Ready() []Move {
+	var ready []Move
+	for _, m := range p.Moves {
+		if m.Conflict == "" {
+			ready = append(ready, m)
+		}
+	}
+	return ready
+}
+
+type Organizer struct {
+	scanner  *library.Scanner
+	template *Template
+	target   string
+}
+
+// creates an organizer moving files below target, following template
+func New(scanner *library.Scanner, template *Template, target string) *Organizer {
+	return &Organizer{scanner, template, target}
+}
+
+// identifies the episode files below dir and plans where they go
+func (o *Organizer) Plan(dir string) (*Plan, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	target, err := filepath.Abs(o.target)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Dir: dir}
+	targets := map[string]int{}
+	// index of the episode move of every subtitle move
+	episodeOf := map[int]int{}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !library.IsVideo(path) {
+			return err
+		}
+
+		f, err := o.scanner.Match(path)
+		if err != nil {
+			plan.Unmatched = append(plan.Unmatched, library.Unmatched{Path: path, Reason: err.Error()})
+			return nil
+		}
+
+		to := filepath.Join(target, o.template.Render(f))
+		if to == path {
+			plan.Organized++
+			return nil
+		}
+
+		episode := len(plan.Moves)
+		plan.Moves = append(plan.Moves, Move{From: path, To: to})
+
+		sidecars, err := sidecars(path)
+		if err != nil {
+			return err
+		}
+		for _, sidecar := range sidecars {
+			suffix := strings.TrimPrefix(sidecar, strings.TrimSuffix(path, filepath.Ext(path)))
+			episodeOf[len(plan.Moves)] = episode
+			plan.Moves = append(plan.Moves, Move{From: sidecar, To: strings.TrimSuffix(to, filepath.Ext(to)) + suffix})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range plan.Moves {
+		m := &plan.Moves[i]
+		if _, err := os.Lstat(m.To); err == nil {
+			m.Conflict = "target already exists"
+		}
+		if j, ok := targets[m.To]; ok {
+			m.Conflict = "same target as " + plan.Moves[j].From
+			plan.Moves[j].Conflict = "same target as " + m.From
+		}
+		targets[m.To] = i
+	}
+
+	// subtitles stay where they are if their episode does
+	for i, episode := range episodeOf {
+		if plan.Moves[i].Conflict == "" && plan.Moves[episode].Conflict != "" {
+			plan.Moves[i].Conflict = "episode is not moved"
+		}
+	}
+	return plan, nil
+}
+
+// the subtitle files belonging to the episode at path
+func sidecars(path string) ([]string, error) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	matches, err := filepath.Glob(globEscape(base) + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var sidecars []string
+	for _, m := range matches {
+		if sidecarExtensions[strings.ToLower(filepath.Ext(m))] {
+			sidecars = append(sidecars, m)
+		}
+	}
+	return sidecars, nil
+}
+
+func globEscape(path string) string {
+	return strings.NewReplacer("*", "\\*", "?", "\\?", "[", "\\[").Replace(path)
+}
+
+// does all moves of the plan without conflicts and writes them to the journal.
+// store, if not nil, is updated with the new paths
+func Apply(plan *Plan, journal string, store *library.Store) ([]Move, error) {
+	batch := time.Now().UTC().Format(time.RFC3339Nano)
+	var done []Move
+	var errs []string
+
+	for _, m := range plan.Ready() {
+		m.Batch = batch
+		if err := move(m.From, m.To); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		// journal every single move, so an interrupted run can be undone as well
+		if err := appendJournal(journal, m); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+
+		if store != nil {
+			store.Move(m.From, m.To)
+		}
+		removeEmptyDirs(filepath.Dir(m.From), plan.Dir)
+	}
+
+	if store != nil && len(done) > 0 {
+		if err := store.Save(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return done, errors.New(strings.Join(errs, "\n"))
+	}
+	return done, nil
+}
+
+// reverts the last run written to the journal and removes it from the journal
+func Undo(journal string, store *library.Store) ([]Move, error) {
+	moves, err := readJournal(journal)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("nothing to undo in %s", journal)
+	}
+
+	batch := moves[len(moves)-1].Batch
+	keep := len(moves)
+	for keep > 0 && moves[keep-1].Batch == batch {
+		keep--
+	}
+
+	var undone []Move
+	for i := len(moves) - 1; i >= keep; i-- {
+		m := moves[i]
+		if err := move(m.To, m.From); err != nil {
+			// keep what could not be undone in the journal
+			return undone, fmt.Errorf("%v, %d moves left in the journal", err, i-keep+1)
+		}
+		if store != nil {
+			store.Move(m.To, m.From)
+		}
+		removeEmptyDirs(filepath.Dir(m.To), filepath.Dir(journal))
+		undone = append(undone, m)
+		if err := writeJournal(journal, moves[:i]); err != nil {
+			return undone, err
+		}
+	}
+
+	if store != nil {
+		return undone, store.Save()
+	}
+	return undone, nil
+}
+
+// moves a file without ever replacing an existing one
+func move(from, to string) error {
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("can not move %s: %s already exists", from, to)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// removes dir and its parents up to root as long as they are empty
+func removeEmptyDirs(dir, root string) {
+	for isBelow(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// returns true if path is inside dir but not dir itself
+func isBelow(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func appendJournal(journal string, m Move) error {
+	f, err := os.OpenFile(journal, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readJournal(journal string) ([]Move, error) {
+	f, err := os.Open(journal)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var moves []Move
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var m Move
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return nil, fmt.Errorf("broken journal %s: %v", journal, err)
+		}
+		moves = append(moves, m)
+	}
+	return moves, scanner.Err()
+}
+
+func writeJournal(journal string, moves []Move) error {
+	if len(moves) == 0 {
+		return os.Remove(journal)
+	}
+
+	f, err := os.Create(journal)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, m := range moves {
+		if err := enc.Encode(m); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
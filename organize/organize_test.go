@@ -0,0 +1,141 @@
+package organize
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/library"
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockOrganizer(t *testing.T, target string) *Organizer {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/tv":
+			w.Write([]byte(`{"results":[{"id":70523,"name":"Dark","first_air_date":"2017-12-01"}]}`))
+		case "/tv/70523/season/1/episode/1":
+			w.Write([]byte(`{"id":1,"name":"Secrets"}`))
+		case "/tv/70523/season/1/episode/2":
+			w.Write([]byte(`{"id":2,"name":"Lies"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client := themoviedb.NewClient(&http.Client{Timeout: 10 * time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(mockServer.URL)
+
+	store, _ := library.OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	template, _ := ParseTemplate(DefaultTemplate)
+	return New(library.NewScanner(client, store), template, target)
+}
+
+func CreateFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+	}
+}
+
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestOrganizeAndUndo(t *testing.T) {
+
+	dir := t.TempDir()
+	CreateFiles(t, dir, "downloads/dark.s01e01.720p.mkv", "downloads/dark.s01e01.720p.en.srt", "dark 1x02.avi", "holiday.mp4")
+	organizer := GetMockOrganizer(t, dir)
+
+	plan, err := organizer.Plan(dir)
+
+	assert.Nil(t, err)
+	assert.Len(t, plan.Moves, 3)
+	assert.Len(t, plan.Ready(), 3)
+	assert.Len(t, plan.Unmatched, 1)
+	assert.True(t, Exists(filepath.Join(dir, "dark 1x02.avi")), "planning should not move anything")
+
+	journal := filepath.Join(dir, JournalName)
+	done, err := Apply(plan, journal, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, done, 3)
+	season := filepath.Join(dir, "Dark (2017)", "Season 01")
+	assert.True(t, Exists(filepath.Join(season, "Dark - S01E01 - Secrets.mkv")))
+	assert.True(t, Exists(filepath.Join(season, "Dark - S01E01 - Secrets.en.srt")))
+	assert.True(t, Exists(filepath.Join(season, "Dark - S01E02 - Lies.avi")))
+	assert.False(t, Exists(filepath.Join(dir, "downloads")), "empty directories should be removed")
+
+	// a second run finds everything organized
+	plan, err = organizer.Plan(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Moves)
+	assert.Equal(t, 2, plan.Organized)
+
+	undone, err := Undo(journal, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, undone, 3)
+	assert.True(t, Exists(filepath.Join(dir, "downloads", "dark.s01e01.720p.mkv")))
+	assert.True(t, Exists(filepath.Join(dir, "downloads", "dark.s01e01.720p.en.srt")))
+	assert.True(t, Exists(filepath.Join(dir, "dark 1x02.avi")))
+	assert.False(t, Exists(filepath.Join(dir, "Dark (2017)")))
+	assert.False(t, Exists(journal), "empty journal should be removed")
+
+	_, err = Undo(journal, nil)
+	assert.NotNil(t, err, "nothing left to undo")
+}
+
+func TestOrganizeConflicts(t *testing.T) {
+
+	dir := t.TempDir()
+	CreateFiles(t, dir,
+		"a/Dark.S01E01.mkv",
+		"b/Dark.S01E01.mkv",
+		"Dark.S01E02.mkv",
+		"Dark.S01E02.de.srt",
+		"Dark (2017)/Season 01/Dark - S01E02 - Lies.mkv",
+	)
+	organizer := GetMockOrganizer(t, dir)
+
+	plan, err := organizer.Plan(dir)
+
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Ready())
+	assert.Equal(t, 1, plan.Organized)
+	for _, m := range plan.Moves {
+		assert.NotEmpty(t, m.Conflict, m.From)
+	}
+
+	done, err := Apply(plan, filepath.Join(dir, JournalName), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, done)
+	assert.True(t, Exists(filepath.Join(dir, "Dark.S01E02.de.srt")))
+}
+
+func TestApplyUpdatesLibrary(t *testing.T) {
+
+	dir := t.TempDir()
+	CreateFiles(t, dir, "dark.s01e01.mkv")
+	from := filepath.Join(dir, "dark.s01e01.mkv")
+
+	store, _ := library.OpenStore(filepath.Join(t.TempDir(), "library.json"))
+	store.Put(library.File{Path: from, ShowID: 70523})
+
+	plan, _ := GetMockOrganizer(t, dir).Plan(dir)
+	done, err := Apply(plan, filepath.Join(dir, JournalName), store)
+
+	assert.Nil(t, err)
+	_, ok := store.Get(done[0].To)
+	assert.True(t, ok, "library should know the new path")
+	_, ok = store.Get(from)
+	assert.False(t, ok)
+}
@@ -0,0 +1,90 @@
+package organize
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/library"
+)
+
+// the default layout of organized episodes
+const DefaultTemplate = "{show} ({year})/Season {season:02}/{show} - S{season:02}E{episode:02} - {title}.{ext}"
+
+// {name} or {name:02} for numbers padded with zeros
+var placeholder = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+var placeholders = map[string]bool{"show": true, "year": true, "season": true, "episode": true, "title": true, "ext": true}
+
+// a parsed naming template. "/" in the template separates directories
+type Template struct {
+	text string
+}
+
+func ParseTemplate(text string) (*Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("template is empty")
+	}
+	for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+		if !placeholders[m[1]] {
+			return nil, fmt.Errorf("unknown placeholder {%s} in template", m[1])
+		}
+	}
+	return &Template{text}, nil
+}
+
+// the relative path of f following the template. files with more than one
+// episode get all of them like S01E01-E02 and their titles joined by " & "
+func (t *Template) Render(f library.File) string {
+	titles := make([]string, 0, len(f.Episodes))
+	for _, e := range f.Episodes {
+		titles = append(titles, e.Name)
+	}
+
+	path := placeholder.ReplaceAllStringFunc(t.text, func(match string) string {
+		m := placeholder.FindStringSubmatch(match)
+		width, _ := strconv.Atoi(m[2])
+
+		switch m[1] {
+		case "show":
+			return sanitize(f.ShowName)
+		case "year":
+			// an unknown year is dropped, season 0 holds the specials
+			if f.ShowYear == 0 {
+				return ""
+			}
+			return pad(f.ShowYear, width)
+		case "season":
+			return pad(f.Season, width)
+		case "episode":
+			numbers := make([]string, 0, len(f.Episodes))
+			for _, e := range f.Episodes {
+				numbers = append(numbers, pad(e.Number, width))
+			}
+			return strings.Join(numbers, "-E")
+		case "title":
+			return sanitize(strings.Join(titles, " & "))
+		case "ext":
+			return strings.TrimPrefix(filepath.Ext(f.Path), ".")
+		}
+		return match
+	})
+
+	// drop what is left of empty values like " ()" for an unknown year
+	path = strings.ReplaceAll(path, " ()", "")
+	path = strings.ReplaceAll(path, " - .", ".")
+	return filepath.FromSlash(path)
+}
+
+func pad(i, width int) string {
+	return fmt.Sprintf("%0*d", width, i)
+}
+
+// removes characters which are not allowed in file names on common file systems
+var forbidden = strings.NewReplacer("/", "-", "\\", "-", ":", " -", "*", "", "?", "", "\"", "'", "<", "", ">", "", "|", "-")
+
+func sanitize(value string) string {
+	return strings.Trim(forbidden.Replace(value), " .")
+}
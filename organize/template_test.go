@@ -0,0 +1,36 @@
+package organize
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bereths.com/netstar/library"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+
+	template, err := ParseTemplate(DefaultTemplate)
+	assert.Nil(t, err)
+
+	f := library.File{Path: "/tmp/dark.s1e3.mkv", ShowName: "Dark", ShowYear: 2017, Season: 1, Episodes: []library.Episode{{Number: 3, Name: "Past and Present"}}}
+	assert.Equal(t, filepath.FromSlash("Dark (2017)/Season 01/Dark - S01E03 - Past and Present.mkv"), template.Render(f))
+
+	f = library.File{Path: "/tmp/x.avi", ShowName: "Law & Order: SVU", Season: 2, Episodes: []library.Episode{{Number: 1, Name: "A/B"}, {Number: 2, Name: "C?"}}}
+	assert.Equal(t, filepath.FromSlash("Law & Order - SVU/Season 02/Law & Order - SVU - S02E01-E02 - A-B & C.avi"), template.Render(f))
+
+	f = library.File{Path: "/tmp/x.mp4", ShowName: "Dark", Season: 1, Episodes: []library.Episode{{Number: 1}}}
+	assert.Equal(t, filepath.FromSlash("Dark/Season 01/Dark - S01E01.mp4"), template.Render(f), "missing year and title should be dropped")
+
+	f = library.File{Path: "/tmp/x.mkv", ShowName: "Dark", ShowYear: 2017, Season: 0, Episodes: []library.Episode{{Number: 1, Name: "Making of"}}}
+	assert.Equal(t, filepath.FromSlash("Dark (2017)/Season 00/Dark - S00E01 - Making of.mkv"), template.Render(f), "specials should keep season 0")
+}
+
+func TestParseTemplate(t *testing.T) {
+
+	_, err := ParseTemplate("{show}/{network}.{ext}")
+	assert.NotNil(t, err, "unknown placeholder should fail")
+
+	_, err = ParseTemplate(" ")
+	assert.NotNil(t, err, "empty template should fail")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"golang.org/x/text/language"
+)
+
+// the cookies the language switcher saves its choice in
+const (
+	languageCookie = "netstar_lang"
+	adultCookie    = "netstar_adult"
+)
+
+// a language visitors can switch netstar to
+type Language struct {
+	Tag  string
+	Name string
+}
+
+// the languages offered in the switcher, besides the one of the server
+var languages = []Language{
+	{"en-US", "English"},
+	{"de-DE", "Deutsch"},
+	{"fr-FR", "Français"},
+	{"es-ES", "Español"},
+	{"it-IT", "Italiano"},
+	{"pt-BR", "Português"},
+	{"ja-JP", "日本語"},
+}
+
+// the language and adult setting of the request, shown in the switcher of every page
+type Preferences struct {
+	Language     string
+	IncludeAdult bool
+	// false if the server hides shows for older audiences
+	AdultAllowed bool
+	Languages    []Language
+	// the page to go back to after switching
+	Next string
+}
+
+func (p *Preferences) options() themoviedb.Options {
+	return themoviedb.Options{Language: p.Language, IncludeAdult: p.IncludeAdult}
+}
+
+type preferencesKey struct{}
+
+// the preferences of the request or nil if they were not looked at
+func requestPreferences(r *http.Request) *Preferences {
+	prefs, _ := r.Context().Value(preferencesKey{}).(*Preferences)
+	return prefs
+}
+
+// finds out the language and adult setting of every request: the choice saved by the switcher,
+// else the Accept-Language header, else the settings of the server
+func PreferencesMiddleware(app *App) func(http.Handler) http.Handler {
+	offered := offeredLanguages(app.TMDB.Language())
+	tags := make([]language.Tag, len(offered))
+	for i, l := range offered {
+		tags[i] = language.Make(l.Tag)
+	}
+	matcher := language.NewMatcher(tags)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefs := &Preferences{
+				Language:     app.TMDB.Language(),
+				IncludeAdult: app.TMDB.IncludeAdult(),
+				AdultAllowed: app.Certifications == nil,
+				Languages:    offered,
+				Next:         r.URL.RequestURI(),
+			}
+
+			if c, err := r.Cookie(languageCookie); err == nil && offers(offered, c.Value) {
+				prefs.Language = c.Value
+			} else if header := r.Header.Get("Accept-Language"); header != "" {
+				accepted, _, _ := language.ParseAcceptLanguage(header)
+				if _, index, confidence := matcher.Match(accepted...); confidence != language.No {
+					prefs.Language = offered[index].Tag
+				}
+			}
+			if c, err := r.Cookie(adultCookie); err == nil {
+				prefs.IncludeAdult, _ = strconv.ParseBool(c.Value)
+			}
+			if !prefs.AdultAllowed {
+				prefs.IncludeAdult = false
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), preferencesKey{}, prefs)))
+		})
+	}
+}
+
+// the switcher languages with the language of the server first
+func offeredLanguages(serverLanguage string) []Language {
+	offered := []Language{}
+	for _, l := range languages {
+		if l.Tag == serverLanguage {
+			offered = append([]Language{l}, offered...)
+		} else {
+			offered = append(offered, l)
+		}
+	}
+	if !offers(offered, serverLanguage) {
+		offered = append([]Language{{serverLanguage, serverLanguage}}, offered...)
+	}
+	return offered
+}
+
+func offers(offered []Language, tag string) bool {
+	for _, l := range offered {
+		if l.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// builds the handler for every request with a themoviedb client in the language and adult setting
+// of the visitor. the answers differ by both, so caches have to tell them apart
+func localized(app *App, handler func(app *App) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		local := *app
+		if prefs := requestPreferences(r); prefs != nil {
+			local.TMDB = app.TMDB.With(prefs.options())
+		}
+		w.Header().Add("Vary", "Accept-Language, Cookie")
+		handler(&local)(w, r)
+	}
+}
+
+// the same for handlers that only need the client
+func localizedClient(app *App, handler func(themoviedbAPI *themoviedb.Client) http.HandlerFunc) http.HandlerFunc {
+	return localized(app, func(app *App) http.HandlerFunc { return handler(app.TMDB) })
+}
+
+// saves the choice of the language switcher in cookies for a year
+func PreferencesHandler(app *App) http.HandlerFunc {
+	offered := offeredLanguages(app.TMDB.Language())
+	secure := app.Users != nil && app.Users.SecureCookies
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := r.PostFormValue("lang")
+		if !offers(offered, lang) {
+			httpError(w, r, errUnknownLanguage, http.StatusBadRequest)
+			return
+		}
+		adult := r.PostFormValue("adult") == "true" && app.Certifications == nil
+
+		for name, value := range map[string]string{languageCookie: lang, adultCookie: strconv.FormatBool(adult)} {
+			http.SetCookie(w, &http.Cookie{
+				Name:     name,
+				Value:    value,
+				Path:     "/",
+				MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+				HttpOnly: true,
+				Secure:   secure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		next := r.PostFormValue("next")
+		if next == "" {
+			next = "/"
+		}
+		http.Redirect(w, r, users.SafeRedirect(next), http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a themoviedb answering in the language and adult setting it was asked with
+func GetMockLanguageServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","overview":"overview in ` + query.Get("language") + `"}`))
+		case "/search/tv":
+			w.Write([]byte(`{"page":1,"results":[{"id":70523,"name":"Dark","overview":"adult ` + query.Get("include_adult") + `"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func GetLanguage(t *testing.T, client *http.Client, target, acceptLanguage string) (*http.Response, string) {
+	req, _ := http.NewRequest("GET", target, nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, ReadBody(t, resp)
+}
+
+func TestLanguageFromAcceptLanguage(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockLanguageServer(t).URL)
+	mockServer := httptest.NewServer(NewRouter(themoviedbAPI))
+	defer mockServer.Close()
+
+	resp, body := GetLanguage(t, http.DefaultClient, mockServer.URL+"/details?id=70523", "fr-CH, fr;q=0.9, en;q=0.8")
+	assert.Contains(t, body, "overview in fr-FR")
+	assert.Contains(t, resp.Header.Get("Vary"), "Accept-Language")
+	assert.Contains(t, body, `<option value="fr-FR" selected>`)
+
+	_, body = GetLanguage(t, http.DefaultClient, mockServer.URL+"/details?id=70523", "nl-NL")
+	assert.Contains(t, body, "overview in de-DE", "unknown languages should fall back to the server's")
+
+	_, body = GetLanguage(t, http.DefaultClient, mockServer.URL+"/api/shows/70523", "en")
+	assert.Contains(t, body, "overview in en-US")
+}
+
+func TestLanguageSwitcher(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockLanguageServer(t).URL)
+	mockServer := httptest.NewServer(NewRouter(themoviedbAPI))
+	defer mockServer.Close()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	resp, err := client.PostForm(mockServer.URL+"/settings/preferences", url.Values{"lang": {"es-ES"}, "next": {"/details?id=70523"}})
+	assert.Nil(t, err)
+	body := ReadBody(t, resp)
+	assert.Equal(t, "/details", resp.Request.URL.Path, "should go back to the page")
+	assert.Contains(t, body, "overview in es-ES")
+
+	_, body = GetLanguage(t, client, mockServer.URL+"/details?id=70523", "fr")
+	assert.Contains(t, body, "overview in es-ES", "the switcher should win over the browser")
+
+	_, body = GetLanguage(t, client, mockServer.URL+"/search?q=dark", "")
+	assert.Contains(t, body, "adult false")
+
+	client.PostForm(mockServer.URL+"/settings/preferences", url.Values{"lang": {"es-ES"}, "adult": {"true"}})
+	_, body = GetLanguage(t, client, mockServer.URL+"/search?q=dark", "")
+	assert.Contains(t, body, "adult true")
+
+	resp, _ = client.PostForm(mockServer.URL+"/settings/preferences", url.Values{"lang": {"xx-XX"}})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAdultNotAllowedWithMaxCertification(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockLanguageServer(t).URL)
+	app := &App{TMDB: themoviedbAPI, Certifications: NewCertificationFilter(themoviedbAPI, "DE", "12")}
+	mockServer := httptest.NewServer(NewAppRouter(app))
+	defer mockServer.Close()
+
+	req, _ := http.NewRequest("GET", mockServer.URL+"/search?q=dark", nil)
+	req.AddCookie(&http.Cookie{Name: adultCookie, Value: "true"})
+	resp, _ := http.DefaultClient.Do(req)
+	body := ReadBody(t, resp)
+
+	assert.NotContains(t, body, `name="adult"`, "the switcher should not offer adult content")
+	assert.NotContains(t, body, "adult true")
+}
+
+func TestFormsUseTheLanguageOfTheVisitor(t *testing.T) {
+
+	var languages []string
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		languages = append(languages, r.URL.Query().Get("language"))
+		w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+	}))
+	defer tmdbServer.Close()
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	mockServer, client, _ := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/watchlist")
+
+	languages = nil
+	req, _ := http.NewRequest("POST", mockServer.URL+"/watchlist", strings.NewReader(url.Values{"csrf_token": {token}, "id": {"70523"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "fr")
+	resp, _ := client.Do(req)
+	resp.Body.Close()
+
+	if assert.NotEmpty(t, languages) {
+		assert.Equal(t, "fr-FR", languages[0])
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+)
+
+// a show the user started with the episode to watch next
+type ContinueItem struct {
+	ShowID     int
+	Name       string
+	PosterPath string
+	Next       *progress.Next
+	Progress   progress.Progress
+}
+
+// shows the next unwatched episode of every show the user is watching
+func ContinueWatchingHandler(themoviedbAPI *themoviedb.Client, pr *progress.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		shows, err := pr.Shows(user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		items := make([]*ContinueItem, len(shows))
+		themoviedb.Parallel(len(shows), func(i int) {
+			item, err := continueItem(themoviedbAPI, pr, user.ID, shows[i])
+			if err != nil {
+				log.Printf("Could not find next episode of %d: %v", shows[i], err)
+				return
+			}
+			items[i] = item
+		})
+
+		// shows the user has caught up with are left out
+		var next []*ContinueItem
+		for _, item := range items {
+			if item != nil && item.Next != nil {
+				next = append(next, item)
+			}
+		}
+
+		render(w, r, continueWatching, next)
+	}
+}
+
+func continueItem(themoviedbAPI *themoviedb.Client, pr *progress.Store, userID, showID int) (*ContinueItem, error) {
+	id := strconv.Itoa(showID)
+	show, err := themoviedbAPI.GetTVShowDetails(id)
+	if err != nil {
+		return nil, err
+	}
+
+	watched, err := pr.Watched(userID, showID)
+	if err != nil {
+		return nil, err
+	}
+
+	season := func(n int) (*themoviedb.TVSeasonDetails, error) {
+		return themoviedbAPI.GetSeasonDetails(id, strconv.Itoa(n))
+	}
+	next, err := progress.NextEpisode(show, watched, season, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	total, _ := progress.ShowProgress(show, watched)
+	return &ContinueItem{ShowID: show.ID, Name: show.Name, PosterPath: show.PosterPath, Next: next, Progress: total}, nil
+}
+
+// marks a single episode as watched or unwatched
+func MarkEpisodeHandler(pr *progress.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		ids, err := formInts(r, "id", "seasonNumber", "episodeNumber")
+		if err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		episode := progress.Episode{Season: ids[1], Number: ids[2]}
+		if err := pr.Mark(user.ID, ids[0], []progress.Episode{episode}, r.PostFormValue("watched") != "false"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		redirectBack(w, r, ids[0], ids[1])
+	}
+}
+
+// marks every episode of a season as watched or unwatched
+func MarkSeasonHandler(themoviedbAPI *themoviedb.Client, pr *progress.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		ids, err := formInts(r, "id", "seasonNumber")
+		if err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		season, err := themoviedbAPI.GetSeasonDetails(strconv.Itoa(ids[0]), strconv.Itoa(ids[1]))
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		if err := pr.Mark(user.ID, ids[0], progress.SeasonEpisodes(season), r.PostFormValue("watched") != "false"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		redirectBack(w, r, ids[0], ids[1])
+	}
+}
+
+// marks the show as watched up to and including the episode
+func MarkUpToHandler(themoviedbAPI *themoviedb.Client, pr *progress.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		ids, err := formInts(r, "id", "seasonNumber", "episodeNumber")
+		if err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		show, err := themoviedbAPI.GetTVShowDetails(strconv.Itoa(ids[0]))
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		episodes := progress.UpTo(show, progress.Episode{Season: ids[1], Number: ids[2]})
+		if err := pr.Mark(user.ID, ids[0], episodes, true); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		redirectBack(w, r, ids[0], ids[1])
+	}
+}
+
+// reads the named integer form values
+func formInts(r *http.Request, names ...string) ([]int, error) {
+	values := make([]int, len(names))
+	for i, name := range names {
+		v, err := strconv.Atoi(r.PostFormValue(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s", name)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// goes back to the page in the next form value or to the season page
+func redirectBack(w http.ResponseWriter, r *http.Request, showID, seasonNumber int) {
+	next := r.PostFormValue("next")
+	if next == "" {
+		next = fmt.Sprintf("/details/season?id=%d&seasonNumber=%d", showID, seasonNumber)
+	}
+	http.Redirect(w, r, users.SafeRedirect(next), http.StatusSeeOther)
+}
@@ -0,0 +1,123 @@
+package progress
+
+import (
+	"sort"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// watched of total episodes
+type Progress struct {
+	Watched int `json:"watched"`
+	Total   int `json:"total"`
+}
+
+func (p Progress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Watched * 100 / p.Total
+}
+
+func (p Progress) Done() bool {
+	return p.Total > 0 && p.Watched >= p.Total
+}
+
+// the progress of the whole show and of every season. specials are left out of the total
+func ShowProgress(show *themoviedb.TVShowDetails, watched Watched) (Progress, map[int]Progress) {
+	total := Progress{}
+	seasons := map[int]Progress{}
+	for _, season := range show.Seasons {
+		p := Progress{Watched: watched.Season(season.SeasonNumber), Total: season.EpisodeCount}
+		if p.Watched > p.Total {
+			p.Watched = p.Total
+		}
+		seasons[season.SeasonNumber] = p
+
+		if season.SeasonNumber != 0 {
+			total.Watched += p.Watched
+			total.Total += p.Total
+		}
+	}
+	return total, seasons
+}
+
+func SeasonProgress(season *themoviedb.TVSeasonDetails, watched Watched) Progress {
+	p := Progress{Total: len(season.Episodes)}
+	for _, ep := range season.Episodes {
+		if watched[Episode{season.SeasonNumber, ep.EpisodeNumber}] {
+			p.Watched++
+		}
+	}
+	return p
+}
+
+// all episodes of season
+func SeasonEpisodes(season *themoviedb.TVSeasonDetails) []Episode {
+	episodes := make([]Episode, 0, len(season.Episodes))
+	for _, ep := range season.Episodes {
+		episodes = append(episodes, Episode{season.SeasonNumber, ep.EpisodeNumber})
+	}
+	return episodes
+}
+
+// the episodes of the earlier seasons and of ep's season up to and including ep.
+// earlier seasons are counted from their episode count, specials are skipped
+func UpTo(show *themoviedb.TVShowDetails, ep Episode) []Episode {
+	var episodes []Episode
+	for _, season := range show.Seasons {
+		if season.SeasonNumber == 0 || season.SeasonNumber >= ep.Season {
+			continue
+		}
+		for n := 1; n <= season.EpisodeCount; n++ {
+			episodes = append(episodes, Episode{season.SeasonNumber, n})
+		}
+	}
+	for n := 1; n <= ep.Number; n++ {
+		episodes = append(episodes, Episode{ep.Season, n})
+	}
+	return episodes
+}
+
+// the episode to watch next
+type Next struct {
+	Episode
+	Name    string `json:"name"`
+	AirDate string `json:"air_date"`
+}
+
+// looks up the season details of the show
+type SeasonFunc func(seasonNumber int) (*themoviedb.TVSeasonDetails, error)
+
+// the first unwatched episode after the furthest watched one. returns nil if the
+// user has seen everything that aired until today (formatted like 2006-01-02)
+func NextEpisode(show *themoviedb.TVShowDetails, watched Watched, season SeasonFunc, today string) (*Next, error) {
+	latest, _ := watched.Latest()
+
+	numbers := []int{}
+	for _, s := range show.Seasons {
+		if s.SeasonNumber != 0 && s.SeasonNumber >= latest.Season && s.EpisodeCount > 0 {
+			numbers = append(numbers, s.SeasonNumber)
+		}
+	}
+	sort.Ints(numbers)
+
+	for _, n := range numbers {
+		details, err := season(n)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ep := range details.Episodes {
+			e := Episode{n, ep.EpisodeNumber}
+			if n == latest.Season && ep.EpisodeNumber <= latest.Number || watched[e] {
+				continue
+			}
+			if ep.AirDate == "" || ep.AirDate > today {
+				return nil, nil
+			}
+			return &Next{e, ep.Name, ep.AirDate}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+// dark with specials and three seasons of 10, 8 and 8 episodes
+func GetMockShow() *themoviedb.TVShowDetails {
+	show := &themoviedb.TVShowDetails{}
+	json.Unmarshal([]byte(`{"id":70523,"name":"Dark","seasons":[
+		{"season_number":0,"episode_count":2},
+		{"season_number":1,"episode_count":10},
+		{"season_number":2,"episode_count":8},
+		{"season_number":3,"episode_count":8}]}`), show)
+	return show
+}
+
+// every episode aired on the first of the season's month in 2020, except season 3 which airs in 2030
+func GetMockSeason(seasonNumber int) (*themoviedb.TVSeasonDetails, error) {
+	count := map[int]int{1: 10, 2: 8, 3: 8}[seasonNumber]
+	year := 2020
+	if seasonNumber == 3 {
+		year = 2030
+	}
+
+	season := &themoviedb.TVSeasonDetails{}
+	body := fmt.Sprintf(`{"season_number":%d,"episodes":[`, seasonNumber)
+	for n := 1; n <= count; n++ {
+		if n > 1 {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"episode_number":%d,"name":"Episode %d","air_date":"%d-%02d-01"}`, n, n, year, seasonNumber)
+	}
+	err := json.Unmarshal([]byte(body+"]}"), season)
+	return season, err
+}
+
+func TestShowProgress(t *testing.T) {
+
+	total, seasons := ShowProgress(GetMockShow(), Watched{{0, 1}: true, {1, 1}: true, {1, 2}: true})
+
+	assert.Equal(t, Progress{2, 26}, total, "specials should not count")
+	assert.Equal(t, Progress{2, 10}, seasons[1])
+	assert.Equal(t, 20, seasons[1].Percent())
+	assert.Equal(t, Progress{1, 2}, seasons[0])
+}
+
+func TestSeasonProgress(t *testing.T) {
+
+	season, _ := GetMockSeason(2)
+
+	p := SeasonProgress(season, Watched{{1, 1}: true, {2, 1}: true})
+	assert.Equal(t, Progress{1, 8}, p)
+	assert.False(t, p.Done())
+
+	watched := Watched{}
+	for _, ep := range SeasonEpisodes(season) {
+		watched[ep] = true
+	}
+	assert.True(t, SeasonProgress(season, watched).Done())
+}
+
+func TestUpTo(t *testing.T) {
+
+	episodes := UpTo(GetMockShow(), Episode{2, 3})
+
+	assert.Len(t, episodes, 13)
+	assert.Equal(t, Episode{1, 1}, episodes[0])
+	assert.Equal(t, Episode{2, 3}, episodes[12])
+}
+
+func TestNextEpisode(t *testing.T) {
+
+	show := GetMockShow()
+	today := "2022-06-01"
+
+	next, err := NextEpisode(show, Watched{}, GetMockSeason, today)
+	assert.Nil(t, err)
+	assert.Equal(t, Episode{1, 1}, next.Episode, "without progress the first episode is next")
+
+	next, _ = NextEpisode(show, Watched{{1, 1}: true, {1, 4}: true}, GetMockSeason, today)
+	assert.Equal(t, Episode{1, 5}, next.Episode, "should continue after the furthest episode")
+	assert.Equal(t, "Episode 5", next.Name)
+
+	next, _ = NextEpisode(show, Watched{{1, 10}: true}, GetMockSeason, today)
+	assert.Equal(t, Episode{2, 1}, next.Episode, "should continue with the next season")
+
+	next, _ = NextEpisode(show, Watched{{2, 8}: true}, GetMockSeason, today)
+	assert.Nil(t, next, "season 3 has not aired yet")
+}
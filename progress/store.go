@@ -0,0 +1,225 @@
+// Package progress remembers which episodes every user has watched and works
+// out how far a user is in a show and which episode comes next.
+package progress
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var watchedBucket = []byte("watched")
+
+// an episode of a show
+type Episode struct {
+	Season int `json:"season"`
+	Number int `json:"episode"`
+}
+
+// the episodes of a show a user has watched
+type Watched map[Episode]bool
+
+// how many episodes of season were watched
+func (w Watched) Season(season int) int {
+	n := 0
+	for ep := range w {
+		if ep.Season == season {
+			n++
+		}
+	}
+	return n
+}
+
+// the episodes of season that were watched, by episode number
+func (w Watched) Episodes(season int) map[int]bool {
+	episodes := map[int]bool{}
+	for ep := range w {
+		if ep.Season == season {
+			episodes[ep.Number] = true
+		}
+	}
+	return episodes
+}
+
+// the furthest watched episode, specials do not count
+func (w Watched) Latest() (Episode, bool) {
+	latest, found := Episode{}, false
+	for ep := range w {
+		if ep.Season == 0 {
+			continue
+		}
+		if !found || ep.Season > latest.Season || ep.Season == latest.Season && ep.Number > latest.Number {
+			latest, found = ep, true
+		}
+	}
+	return latest, found
+}
+
+// the watched episodes of all users in the bolt database, nested by user and show
+type Store struct {
+	db *bolt.DB
+}
+
+func NewStore(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(watchedBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// marks episodes of the show as watched or unwatched in one transaction
+func (s *Store) Mark(userID, showID int, episodes []Episode, watched bool) error {
+	now, _ := time.Now().UTC().MarshalBinary()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := tx.Bucket(watchedBucket).CreateBucketIfNotExists(itob(userID))
+		if err != nil {
+			return err
+		}
+		show, err := user.CreateBucketIfNotExists(itob(showID))
+		if err != nil {
+			return err
+		}
+
+		for _, ep := range episodes {
+			if watched {
+				err = show.Put(episodeKey(ep), now)
+			} else {
+				err = show.Delete(episodeKey(ep))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// a watched episode of a show and when it was marked
+type Mark struct {
+	ShowID  int
+	Episode Episode
+	At      time.Time
+}
+
+// all episodes the user has watched, ordered by show, season and episode
+func (s *Store) History(userID int) ([]Mark, error) {
+	marks := []Mark{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		user := tx.Bucket(watchedBucket).Bucket(itob(userID))
+		if user == nil {
+			return nil
+		}
+		return user.ForEach(func(k, _ []byte) error {
+			id := int(binary.BigEndian.Uint64(k))
+			return user.Bucket(k).ForEach(func(ep, v []byte) error {
+				mark := Mark{ShowID: id, Episode: Episode{int(binary.BigEndian.Uint32(ep)), int(binary.BigEndian.Uint32(ep[4:]))}}
+				if err := mark.At.UnmarshalBinary(v); err != nil {
+					return err
+				}
+				marks = append(marks, mark)
+				return nil
+			})
+		})
+	})
+	return marks, err
+}
+
+// marks the episodes as watched at the times they were watched elsewhere, restoring
+// the same marks twice changes nothing
+func (s *Store) Restore(userID int, marks []Mark) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := tx.Bucket(watchedBucket).CreateBucketIfNotExists(itob(userID))
+		if err != nil {
+			return err
+		}
+		for _, mark := range marks {
+			show, err := user.CreateBucketIfNotExists(itob(mark.ShowID))
+			if err != nil {
+				return err
+			}
+			at, err := mark.At.UTC().MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := show.Put(episodeKey(mark.Episode), at); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// the watched episodes of the show
+func (s *Store) Watched(userID, showID int) (Watched, error) {
+	watched := Watched{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		show := showBucket(tx, userID, showID)
+		if show == nil {
+			return nil
+		}
+		return show.ForEach(func(k, _ []byte) error {
+			watched[Episode{int(binary.BigEndian.Uint32(k)), int(binary.BigEndian.Uint32(k[4:]))}] = true
+			return nil
+		})
+	})
+	return watched, err
+}
+
+// the shows the user has watched episodes of, the most recently watched first
+func (s *Store) Shows(userID int) ([]int, error) {
+	last := map[int]time.Time{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		user := tx.Bucket(watchedBucket).Bucket(itob(userID))
+		if user == nil {
+			return nil
+		}
+		return user.ForEach(func(k, _ []byte) error {
+			id := int(binary.BigEndian.Uint64(k))
+			return user.Bucket(k).ForEach(func(_, v []byte) error {
+				var t time.Time
+				if err := t.UnmarshalBinary(v); err != nil {
+					return err
+				}
+				if _, ok := last[id]; !ok || t.After(last[id]) {
+					last[id] = t
+				}
+				return nil
+			})
+		})
+	})
+
+	shows := make([]int, 0, len(last))
+	for id := range last {
+		shows = append(shows, id)
+	}
+	sort.Slice(shows, func(i, j int) bool { return last[shows[i]].After(last[shows[j]]) })
+	return shows, err
+}
+
+func showBucket(tx *bolt.Tx, userID, showID int) *bolt.Bucket {
+	user := tx.Bucket(watchedBucket).Bucket(itob(userID))
+	if user == nil {
+		return nil
+	}
+	return user.Bucket(itob(showID))
+}
+
+// season and episode big endian, so the episodes of a show are stored in order
+func episodeKey(ep Episode) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, uint32(ep.Season))
+	binary.BigEndian.PutUint32(b[4:], uint32(ep.Number))
+	return b
+}
+
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
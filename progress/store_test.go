@@ -0,0 +1,88 @@
+package progress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestMarkAndWatched(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	assert.Nil(t, store.Mark(1, 70523, []Episode{{1, 1}, {1, 2}, {2, 1}}, true))
+	assert.Nil(t, store.Mark(1, 70523, []Episode{{1, 2}}, false))
+	assert.Nil(t, store.Mark(2, 70523, []Episode{{3, 8}}, true))
+
+	watched, err := store.Watched(1, 70523)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Watched{{1, 1}: true, {2, 1}: true}, watched)
+	assert.Equal(t, 1, watched.Season(1))
+	assert.Equal(t, map[int]bool{1: true}, watched.Episodes(2))
+
+	watched, _ = store.Watched(3, 70523)
+	assert.Empty(t, watched, "user without progress")
+}
+
+func TestShowsMostRecentFirst(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	store.Mark(1, 70523, []Episode{{1, 1}}, true)
+	time.Sleep(time.Millisecond)
+	store.Mark(1, 1399, []Episode{{1, 1}}, true)
+
+	shows, err := store.Shows(1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1399, 70523}, shows)
+}
+
+func TestLatest(t *testing.T) {
+
+	_, found := Watched{}.Latest()
+	assert.False(t, found)
+
+	latest, found := Watched{{0, 9}: true, {1, 10}: true, {2, 3}: true, {2, 1}: true}.Latest()
+	assert.True(t, found)
+	assert.Equal(t, Episode{2, 3}, latest, "specials should not count")
+}
+
+func TestHistoryAndRestore(t *testing.T) {
+
+	store := GetTestStore(t)
+	watched := time.Date(2021, 3, 4, 20, 0, 0, 0, time.UTC)
+	marks := []Mark{
+		{ShowID: 70523, Episode: Episode{1, 1}, At: watched},
+		{ShowID: 70523, Episode: Episode{1, 2}, At: watched.Add(time.Hour)},
+		{ShowID: 1399, Episode: Episode{1, 1}, At: watched},
+	}
+
+	assert.Nil(t, store.Restore(1, marks))
+	assert.Nil(t, store.Restore(1, marks), "restoring twice should not fail")
+
+	history, err := store.History(1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Mark{marks[2], marks[0], marks[1]}, history)
+	empty, _ := store.History(2)
+	assert.Empty(t, empty)
+}
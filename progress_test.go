@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"bereths.com/netstar/progress"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// a themoviedb knowing dark with two aired seasons of three episodes
+func GetMockProgressServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","seasons":[{"season_number":1,"episode_count":3},{"season_number":2,"episode_count":3}]}`))
+		case "/tv/70523/season/1", "/tv/70523/season/2":
+			season := strings.TrimPrefix(r.URL.Path, "/tv/70523/season/")
+			fmt.Fprintf(w, `{"name":"Season %s","season_number":%s,"episodes":[
+				{"episode_number":1,"name":"S%s Secrets","air_date":"2017-12-01"},
+				{"episode_number":2,"name":"S%s Lies","air_date":"2017-12-01"},
+				{"episode_number":3,"name":"S%s Past and Present","air_date":"2017-12-01"}]}`, season, season, season, season, season)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestMarkEpisodesAndContinueWatching(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockProgressServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/continue")
+	all, _ := app.Users.Store.All()
+	userID := all[0].ID
+
+	resp, _ := client.PostForm(mockServer.URL+"/progress/upto", url.Values{"csrf_token": {token}, "id": {"70523"}, "seasonNumber": {"2"}, "episodeNumber": {"1"}})
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `value="1" max="3"`, "season page should show its progress")
+
+	watched, _ := app.Progress.Watched(userID, 70523)
+	assert.Len(t, watched, 4, "season 1 and the first episode of season 2 should be watched")
+
+	resp, _ = client.Get(mockServer.URL + "/continue")
+	assert.Contains(t, ReadBody(t, resp), "S2 Lies")
+
+	resp, _ = client.PostForm(mockServer.URL+"/progress/episode", url.Values{"csrf_token": {token}, "id": {"70523"}, "seasonNumber": {"2"}, "episodeNumber": {"1"}, "watched": {"false"}})
+	resp.Body.Close()
+	watched, _ = app.Progress.Watched(userID, 70523)
+	assert.False(t, watched[progress.Episode{Season: 2, Number: 1}])
+
+	resp, _ = client.PostForm(mockServer.URL+"/progress/season", url.Values{"csrf_token": {token}, "id": {"70523"}, "seasonNumber": {"2"}})
+	resp.Body.Close()
+	watched, _ = app.Progress.Watched(userID, 70523)
+	assert.Len(t, watched, 6)
+
+	resp, _ = client.Get(mockServer.URL + "/continue")
+	assert.Contains(t, ReadBody(t, resp), "Nothing to continue", "the user has seen every episode")
+
+	resp, _ = client.Get(mockServer.URL + "/details?id=70523")
+	assert.Contains(t, ReadBody(t, resp), `value="6" max="6"`, "show page should show the progress")
+}
+
+func TestMarkEpisodeWithInvalidForm(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/continue")
+
+	resp, _ := client.PostForm(mockServer.URL+"/progress/episode", url.Values{"csrf_token": {token}, "id": {"70523"}, "seasonNumber": {"one"}})
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestContinueWatchingBoundsConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	themoviedbAPI := themoviedbtest.NewClient(t, concurrency.Slow(DarkHandler))
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	all, _ := app.Users.Store.All()
+	for id := 1; id <= 30; id++ {
+		app.Progress.Mark(all[0].ID, id, []progress.Episode{{Season: 1, Number: 1}}, true)
+	}
+
+	resp, _ := client.Get(mockServer.URL + "/continue")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Greater(t, concurrency.Max(), 0)
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+)
+
+// the region if neither the server nor the user chose one
+const defaultRegion = "US"
+
+// where the show can be watched in the region of the visitor
+type ProvidersSection struct {
+	Region string
+	// the countries the show is available in, to switch between them
+	Regions []string
+	// nil if the show is not available in Region
+	*themoviedb.RegionProviders
+}
+
+// providers offering the show the same way, like "Rent"
+type ProviderGroup struct {
+	// the message id of the way
+	Label     string
+	Providers []themoviedb.Provider
+}
+
+// the ways the show is offered in the region, streaming first
+func (s *ProvidersSection) Groups() []ProviderGroup {
+	if s.RegionProviders == nil {
+		return nil
+	}
+	var groups []ProviderGroup
+	for _, g := range []ProviderGroup{
+		{"providers.stream", s.Flatrate},
+		{"providers.free", s.Free},
+		{"providers.ads", s.Ads},
+		{"providers.rent", s.Rent},
+		{"providers.buy", s.Buy},
+	} {
+		if len(g.Providers) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// the region of the request: ?region=DE, the user's choice or the default of the server
+func requestRegion(app *App, r *http.Request) string {
+	if region := strings.ToUpper(r.URL.Query().Get("region")); region != "" {
+		return region
+	}
+	if user := users.CurrentUser(r); user != nil && user.Region != "" {
+		return user.Region
+	}
+	if app.Region != "" {
+		return app.Region
+	}
+	return defaultRegion
+}
+
+// the providers of the show
+func loadProviders(app *App, r *http.Request, showID int) *ProvidersSection {
+	providers, err := app.TMDB.GetWatchProviders(strconv.Itoa(showID))
+	if !fetched(err, "providers of %d", showID) {
+		return nil
+	}
+
+	section := &ProvidersSection{Region: requestRegion(app, r), Regions: providers.Regions()}
+	if region, ok := providers.Results[section.Region]; ok {
+		section.RegionProviders = &region
+	}
+	return section
+}
+
+// saves the region of the logged in user and goes back to the page of the form
+func RegionHandler(manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := manager.Store.SetRegion(user.ID, r.PostFormValue("region")); err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		next := r.PostFormValue("next")
+		if next == "" {
+			next = "/"
+		}
+		http.Redirect(w, r, users.SafeRedirect(next), http.StatusSeeOther)
+	}
+}
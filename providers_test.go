@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockProviderServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+		case "/tv/70523/watch/providers":
+			w.Write([]byte(`{"id":70523,"results":{
+				"US":{"link":"https://www.themoviedb.org/tv/70523/watch?locale=US","flatrate":[{"provider_id":8,"provider_name":"Netflix","logo_path":"/netflix.jpg"}]},
+				"DE":{"link":"https://www.themoviedb.org/tv/70523/watch?locale=DE","buy":[{"provider_id":2,"provider_name":"Apple iTunes","logo_path":"/itunes.jpg"}]}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestDetailsShowProvidersOfRegion(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockProviderServer(t).URL)
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI, Region: "US"}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, "Where to watch in US")
+	assert.Contains(t, body, `alt="Netflix"`)
+	assert.NotContains(t, body, "Apple iTunes")
+
+	resp, _ = http.Get(mockServer.URL + "/details?id=70523&region=de")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, `alt="Apple iTunes"`)
+	assert.Contains(t, body, "Buy")
+
+	resp, _ = http.Get(mockServer.URL + "/details?id=70523&region=FR")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "Not available in FR.")
+}
+
+func TestDetailsWithoutProviders(t *testing.T) {
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tv/70523" {
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer tmdbServer.Close()
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the page should work without providers")
+	assert.NotContains(t, body, "Where to watch")
+}
+
+func TestUserRegionOverridesDefault(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockProviderServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	app.Region = "US"
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/details?id=70523")
+
+	resp, _ := client.PostForm(mockServer.URL+"/settings/region", url.Values{"csrf_token": {token}, "region": {"DE"}, "next": {"/details?id=70523"}})
+	body := ReadBody(t, resp)
+	assert.Equal(t, "/details", resp.Request.URL.Path)
+	assert.Contains(t, body, "Where to watch in DE")
+	assert.Contains(t, body, "Save region")
+
+	resp, _ = client.PostForm(mockServer.URL+"/settings/region", url.Values{"csrf_token": {token}, "region": {"Germany"}})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestConfigRegion(t *testing.T) {
+
+	assert.Equal(t, "AT", Config{Region: "at", Language: "de-DE"}.region())
+	assert.Equal(t, "DE", Config{Language: "de-DE"}.region())
+	assert.Equal(t, "US", Config{Language: "en"}.region())
+}
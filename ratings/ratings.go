@@ -0,0 +1,280 @@
+// Package ratings keeps the ratings and short reviews the users of netstar give
+// shows, seasons and episodes.
+package ratings
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var reviewsBucket = []byte("reviews")
+
+// what can be rated
+const (
+	Show    = "show"
+	Season  = "season"
+	Episode = "episode"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 10
+	// reviews are meant to be short
+	MaxTextLength = 1000
+)
+
+var ErrInvalidTarget = errors.New("invalid show, season or episode")
+
+// a show, a season of a show or an episode. season and episode are left zero if they do not apply
+type Target struct {
+	Kind    string `json:"kind"`
+	ShowID  int    `json:"show_id"`
+	Season  int    `json:"season,omitempty"`
+	Episode int    `json:"episode,omitempty"`
+}
+
+func (t Target) Valid() bool {
+	switch t.Kind {
+	case Show:
+		return t.ShowID > 0 && t.Season == 0 && t.Episode == 0
+	case Season:
+		return t.ShowID > 0 && t.Season >= 0 && t.Episode == 0
+	case Episode:
+		return t.ShowID > 0 && t.Season >= 0 && t.Episode > 0
+	}
+	return false
+}
+
+// the page of the target on netstar
+func (t Target) Path() string {
+	switch t.Kind {
+	case Season:
+		return fmt.Sprintf("/details/season?id=%d&seasonNumber=%d", t.ShowID, t.Season)
+	case Episode:
+		return fmt.Sprintf("/details/episode?id=%d&seasonNumber=%d&episodeNumber=%d", t.ShowID, t.Season, t.Episode)
+	}
+	return fmt.Sprintf("/details?id=%d", t.ShowID)
+}
+
+func (t Target) key() []byte {
+	return []byte(fmt.Sprintf("%s/%d/%d/%d", t.Kind, t.ShowID, t.Season, t.Episode))
+}
+
+// a rating of a user with an optional text
+type Review struct {
+	Target   Target `json:"target"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	// the name of the target when it was rated, like "Dark S01E03 Past and Present"
+	Name    string    `json:"name"`
+	Rating  int       `json:"rating"`
+	Text    string    `json:"text,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// the team's opinion
+type Summary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// a rated target with the team's average
+type Ranked struct {
+	Target  Target  `json:"target"`
+	Name    string  `json:"name"`
+	Summary Summary `json:"summary"`
+}
+
+// the reviews in the bolt database, a bucket per target keyed by user id
+type Store struct {
+	db *bolt.DB
+}
+
+func NewStore(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(reviewsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// validates and saves the review, replacing an earlier one of the same user
+func (s *Store) Put(review Review) error {
+	if err := validate(review); err != nil {
+		return err
+	}
+	review.Text = strings.TrimSpace(review.Text)
+	review.Updated = time.Now().UTC()
+	return s.put(review)
+}
+
+// saves a review exported earlier, keeping the time it was written
+func (s *Store) Restore(review Review) error {
+	if review.Updated.IsZero() {
+		return s.Put(review)
+	}
+	if err := validate(review); err != nil {
+		return err
+	}
+	review.Text = strings.TrimSpace(review.Text)
+	review.Updated = review.Updated.UTC()
+	return s.put(review)
+}
+
+func (s *Store) put(review Review) error {
+	b, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(reviewsBucket).CreateBucketIfNotExists(review.Target.key())
+		if err != nil {
+			return err
+		}
+		return bucket.Put(itob(review.UserID), b)
+	})
+}
+
+func validate(review Review) error {
+	if !review.Target.Valid() {
+		return ErrInvalidTarget
+	}
+	if review.Rating < MinRating || review.Rating > MaxRating {
+		return fmt.Errorf("rating must be between %d and %d", MinRating, MaxRating)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(review.Text)) > MaxTextLength {
+		return fmt.Errorf("review must not be longer than %d characters", MaxTextLength)
+	}
+	return nil
+}
+
+func (s *Store) Delete(userID int, target Target) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewsBucket).Bucket(target.key())
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(itob(userID))
+	})
+}
+
+// the reviews of target, the latest first
+func (s *Store) For(target Target) ([]Review, error) {
+	reviews := []Review{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewsBucket).Bucket(target.key())
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var review Review
+			if err := json.Unmarshal(v, &review); err != nil {
+				return err
+			}
+			reviews = append(reviews, review)
+			return nil
+		})
+	})
+
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].Updated.After(reviews[j].Updated) })
+	return reviews, err
+}
+
+// all reviews of the user, ordered by target
+func (s *Store) ByUser(userID int) ([]Review, error) {
+	reviews := []Review{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(reviewsBucket).ForEach(func(k, _ []byte) error {
+			b := tx.Bucket(reviewsBucket).Bucket(k).Get(itob(userID))
+			if b == nil {
+				return nil
+			}
+			var review Review
+			if err := json.Unmarshal(b, &review); err != nil {
+				return err
+			}
+			reviews = append(reviews, review)
+			return nil
+		})
+	})
+	return reviews, err
+}
+
+// the average rating of reviews
+func Summarize(reviews []Review) Summary {
+	summary := Summary{Count: len(reviews)}
+	if summary.Count == 0 {
+		return summary
+	}
+	sum := 0
+	for _, review := range reviews {
+		sum += review.Rating
+	}
+	summary.Average = float64(sum) / float64(summary.Count)
+	return summary
+}
+
+// the targets of kind with at least minCount ratings, the best first
+func (s *Store) Top(kind string, minCount, limit int) ([]Ranked, error) {
+	ranked := []Ranked{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(kind + "/")
+		c := tx.Bucket(reviewsBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, _ = c.Next() {
+			var reviews []Review
+			err := tx.Bucket(reviewsBucket).Bucket(k).ForEach(func(_, v []byte) error {
+				var review Review
+				if err := json.Unmarshal(v, &review); err != nil {
+					return err
+				}
+				reviews = append(reviews, review)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(reviews) == 0 || len(reviews) < minCount {
+				continue
+			}
+
+			// the latest name wins, names change with the language
+			latest := reviews[0]
+			for _, review := range reviews {
+				if review.Updated.After(latest.Updated) {
+					latest = review
+				}
+			}
+			ranked = append(ranked, Ranked{latest.Target, latest.Name, Summarize(reviews)})
+		}
+		return nil
+	})
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i].Summary, ranked[j].Summary
+		if a.Average != b.Average {
+			return a.Average > b.Average
+		}
+		return a.Count > b.Count
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, err
+}
+
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
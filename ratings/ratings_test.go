@@ -0,0 +1,110 @@
+package ratings
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestPutAndFor(t *testing.T) {
+
+	store := GetTestStore(t)
+	dark := Target{Kind: Show, ShowID: 70523}
+
+	assert.Nil(t, store.Put(Review{Target: dark, UserID: 1, Username: "jonas", Name: "Dark", Rating: 7}))
+	assert.Nil(t, store.Put(Review{Target: dark, UserID: 1, Username: "jonas", Name: "Dark", Rating: 10, Text: "  Mind blowing "}))
+	assert.Nil(t, store.Put(Review{Target: dark, UserID: 2, Username: "martha", Name: "Dark", Rating: 8}))
+
+	reviews, err := store.For(dark)
+
+	assert.Nil(t, err)
+	assert.Len(t, reviews, 2, "users should have one review per target")
+	assert.Equal(t, "martha", reviews[0].Username, "latest review should come first")
+	assert.Equal(t, "Mind blowing", reviews[1].Text)
+	assert.Equal(t, Summary{Average: 9, Count: 2}, Summarize(reviews))
+
+	assert.Nil(t, store.Delete(1, dark))
+	reviews, _ = store.For(dark)
+	assert.Len(t, reviews, 1)
+
+	reviews, _ = store.For(Target{Kind: Season, ShowID: 70523, Season: 1})
+	assert.Empty(t, reviews, "season reviews are separate")
+}
+
+func TestPutValidates(t *testing.T) {
+
+	store := GetTestStore(t)
+	dark := Target{Kind: Show, ShowID: 70523}
+
+	assert.NotNil(t, store.Put(Review{Target: dark, UserID: 1, Rating: 0}))
+	assert.NotNil(t, store.Put(Review{Target: dark, UserID: 1, Rating: 11}))
+	assert.NotNil(t, store.Put(Review{Target: dark, UserID: 1, Rating: 5, Text: strings.Repeat("a", MaxTextLength+1)}))
+	assert.Equal(t, ErrInvalidTarget, store.Put(Review{Target: Target{Kind: Episode, ShowID: 70523, Season: 1}, UserID: 1, Rating: 5}))
+	assert.Equal(t, ErrInvalidTarget, store.Put(Review{Target: Target{Kind: "movie", ShowID: 1}, UserID: 1, Rating: 5}))
+}
+
+func TestTop(t *testing.T) {
+
+	store := GetTestStore(t)
+	store.Put(Review{Target: Target{Kind: Show, ShowID: 1}, UserID: 1, Name: "Good", Rating: 7})
+	store.Put(Review{Target: Target{Kind: Show, ShowID: 2}, UserID: 1, Name: "Best", Rating: 9})
+	store.Put(Review{Target: Target{Kind: Show, ShowID: 2}, UserID: 2, Name: "Best", Rating: 10})
+	store.Put(Review{Target: Target{Kind: Show, ShowID: 3}, UserID: 1, Name: "Once", Rating: 10})
+	store.Put(Review{Target: Target{Kind: Episode, ShowID: 1, Season: 1, Episode: 1}, UserID: 1, Name: "Pilot", Rating: 10})
+
+	top, err := store.Top(Show, 1, 10)
+	assert.Nil(t, err)
+	if assert.Len(t, top, 3, "episodes should not be listed with shows") {
+		assert.Equal(t, "Once", top[0].Name)
+		assert.Equal(t, "Best", top[1].Name)
+		assert.Equal(t, Summary{9.5, 2}, top[1].Summary)
+	}
+
+	top, _ = store.Top(Show, 2, 10)
+	assert.Len(t, top, 1, "shows with less ratings should be left out")
+
+	top, _ = store.Top(Show, 1, 1)
+	assert.Len(t, top, 1)
+}
+
+func TestTargetPath(t *testing.T) {
+
+	assert.Equal(t, "/details?id=1", Target{Kind: Show, ShowID: 1}.Path())
+	assert.Equal(t, "/details/season?id=1&seasonNumber=0", Target{Kind: Season, ShowID: 1}.Path())
+	assert.Equal(t, "/details/episode?id=1&seasonNumber=2&episodeNumber=3", Target{Kind: Episode, ShowID: 1, Season: 2, Episode: 3}.Path())
+}
+
+func TestByUserAndRestore(t *testing.T) {
+
+	store := GetTestStore(t)
+	written := time.Date(2021, 3, 4, 20, 0, 0, 0, time.UTC)
+	review := Review{Target: Target{Kind: Episode, ShowID: 70523, Season: 1, Episode: 3}, UserID: 1, Username: "jonas", Name: "Dark S01E03", Rating: 9, Updated: written}
+
+	assert.Nil(t, store.Restore(review))
+	assert.Nil(t, store.Restore(review), "restoring twice should not fail")
+	assert.Nil(t, store.Put(Review{Target: Target{Kind: Show, ShowID: 70523}, UserID: 2, Username: "martha", Rating: 8}))
+	assert.NotNil(t, store.Restore(Review{Target: Target{Kind: Show, ShowID: 70523}, UserID: 1, Rating: 0, Updated: written}))
+
+	reviews, err := store.ByUser(1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Review{review}, reviews, "restored reviews should keep their date")
+}
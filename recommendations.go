@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+)
+
+// the most shows shown in a rail
+const railLength = 12
+
+// how many of the latest shows on the watchlist recommendations are based on
+const becauseYouWatchedShows = 5
+
+// a row of shows like "Similar shows"
+type Rail struct {
+	// the message id of the title
+	Title string
+	// the show the rail is based on, the argument of the title
+	Show  string
+	Shows []themoviedb.TVShow
+}
+
+// the shows recommended for the watchlist of the user
+type RecommendationsPage struct {
+	Rails []Rail
+	// false if there is nothing on the watchlist to base recommendations on
+	HasWatchlist bool
+}
+
+// appends a rail of shows unless there are none
+func appendRail(rails []Rail, title, show string, shows []themoviedb.TVShow) []Rail {
+	if len(shows) > railLength {
+		shows = shows[:railLength]
+	}
+	if len(shows) == 0 {
+		return rails
+	}
+	return append(rails, Rail{Title: title, Show: show, Shows: shows})
+}
+
+// the shows of results
+func railShows(title string, results *themoviedb.Results, err error) []themoviedb.TVShow {
+	if !fetched(err, "%s", title) {
+		return nil
+	}
+	return results.Results
+}
+
+// the recommended and similar shows on the details page
+func loadRails(themoviedbAPI *themoviedb.Client, filter *CertificationFilter, id string) []Rail {
+	titles := []string{"recommendations", "similar shows"}
+	fetches := []func(id, page string) (*themoviedb.Results, error){themoviedbAPI.GetRecommendations, themoviedbAPI.GetSimilarTVShows}
+	shows := make([][]themoviedb.TVShow, len(fetches))
+	themoviedb.Parallel(len(fetches), func(i int) {
+		results, err := fetches[i](id, "1")
+		shows[i] = railShows(titles[i], results, err)
+	})
+	shows = filterRails(filter, shows)
+
+	rails := appendRail(nil, "rails.recommendations", "", shows[0])
+	return appendRail(rails, "rails.similar", "", shows[1])
+}
+
+// the shows of every rail the filter lets through. the shows of all rails are filtered at once,
+// so their certifications are looked up with one bounded fan out instead of one per rail
+func filterRails(filter *CertificationFilter, rails [][]themoviedb.TVShow) [][]themoviedb.TVShow {
+	if filter == nil {
+		return rails
+	}
+	var all []themoviedb.TVShow
+	for _, shows := range rails {
+		all = append(all, shows...)
+	}
+	allowed := map[int]bool{}
+	for _, show := range filter.Filter(all) {
+		allowed[show.ID] = true
+	}
+
+	filtered := make([][]themoviedb.TVShow, len(rails))
+	for i, shows := range rails {
+		for _, show := range shows {
+			if allowed[show.ID] {
+				filtered[i] = append(filtered[i], show)
+			}
+		}
+	}
+	return filtered
+}
+
+// a rail for each of the latest shows on the watchlist with the recommendations that are
+// neither on the watchlist nor in an earlier rail
+func becauseYouWatched(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, filter *CertificationFilter, userID int) ([]Rail, bool, error) {
+	entries, err := wl.List(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	seen := map[int]bool{}
+	for _, entry := range entries {
+		seen[entry.ShowID] = true
+	}
+	if len(entries) > becauseYouWatchedShows {
+		entries = entries[:becauseYouWatchedShows]
+	}
+
+	recommended := make([][]themoviedb.TVShow, len(entries))
+	themoviedb.Parallel(len(entries), func(i int) {
+		results, err := themoviedbAPI.GetRecommendations(strconv.Itoa(entries[i].ShowID), "1")
+		recommended[i] = railShows("recommendations for "+entries[i].Name, results, err)
+	})
+	recommended = filterRails(filter, recommended)
+
+	var rails []Rail
+	for i, entry := range entries {
+		var fresh []themoviedb.TVShow
+		for _, show := range recommended[i] {
+			if !seen[show.ID] {
+				seen[show.ID] = true
+				fresh = append(fresh, show)
+			}
+		}
+		rails = appendRail(rails, "rails.because_you_watched", entry.Name, fresh)
+	}
+	return rails, len(entries) > 0, nil
+}
+
+// shows recommendations based on the watchlist of the logged in user
+func RecommendationsHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, filter *CertificationFilter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		rails, hasWatchlist, err := becauseYouWatched(themoviedbAPI, wl, filter, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		render(w, r, recommendationsPage, &RecommendationsPage{Rails: rails, HasWatchlist: hasWatchlist})
+	}
+}
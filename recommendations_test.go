@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockRecommendationServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+		case "/tv/70523/recommendations":
+			w.Write([]byte(`{"page":1,"results":[{"id":90660,"name":"1899","poster_path":"/1899.jpg"},{"id":66732,"name":"Stranger Things"},{"id":69851,"name":"The OA"}]}`))
+		case "/tv/70523/similar":
+			w.Write([]byte(`{"page":1,"results":[{"id":1399,"name":"Game of Thrones"}]}`))
+		case "/tv/66732/recommendations":
+			w.Write([]byte(`{"page":1,"results":[{"id":70523,"name":"Dark"},{"id":90660,"name":"1899"},{"id":71446,"name":"Money Heist"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestDetailsShowRails(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockRecommendationServer(t).URL)
+	mockServer := httptest.NewServer(NewRouter(themoviedbAPI))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Recommendations")
+	assert.Contains(t, body, `href="/details?id=90660"`)
+	assert.Contains(t, body, "/img/w185/1899.jpg")
+	assert.Contains(t, body, "Similar shows")
+	assert.Contains(t, body, "Game of Thrones")
+}
+
+func TestRecommendationsBecauseYouWatched(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockRecommendationServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+
+	resp, _ := client.Get(mockServer.URL + "/recommendations")
+	assert.Contains(t, resp.Request.URL.String(), "/login?next=%2Frecommendations", "should redirect to login")
+	resp.Body.Close()
+
+	LoginTestUser(t, mockServer, client)
+	resp, _ = client.Get(mockServer.URL + "/recommendations")
+	assert.Contains(t, ReadBody(t, resp), "Add shows to your")
+
+	now := time.Now()
+	app.Watchlist.Add(1, watchlist.Entry{ShowID: 70523, Name: "Dark", Added: now.Add(-time.Hour)})
+	app.Watchlist.Add(1, watchlist.Entry{ShowID: 66732, Name: "Stranger Things", Added: now})
+
+	resp, _ = client.Get(mockServer.URL + "/recommendations")
+	body := ReadBody(t, resp)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Because you watched Stranger Things")
+	assert.Contains(t, body, "Because you watched Dark")
+	assert.Less(t, strings.Index(body, "Because you watched Stranger Things"), strings.Index(body, "Because you watched Dark"), "the latest show should come first")
+	assert.Contains(t, body, "Money Heist")
+	assert.Contains(t, body, "The OA")
+	assert.NotContains(t, body, `href="/details?id=66732"`, "shows on the watchlist should not be recommended")
+	assert.Equal(t, 1, strings.Count(body, `href="/details?id=90660"`), "shows should be recommended once")
+}
+
+func TestRecommendationsBoundConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	themoviedbAPI := themoviedbtest.NewClient(t, concurrency.Slow(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/certification/tv/list":
+			w.Write([]byte(`{"certifications":{"DE":[{"certification":"0","order":1}]}}`))
+		case strings.HasSuffix(r.URL.Path, "/recommendations"):
+			shows := make([]string, 20)
+			for i := range shows {
+				shows[i] = fmt.Sprintf(`{"id":%d,"name":"Show"}`, 1000+i)
+			}
+			fmt.Fprintf(w, `{"page":1,"results":[%s]}`, strings.Join(shows, ","))
+		default:
+			w.Write([]byte(`{"results":[{"iso_3166_1":"DE","rating":"0"}]}`))
+		}
+	}))
+	wl, _ := watchlist.NewStore(GetTestDB(t))
+	for id := 1; id <= 5; id++ {
+		wl.Add(1, watchlist.Entry{ShowID: id, Name: "Watched"})
+	}
+
+	rails, _, err := becauseYouWatched(themoviedbAPI, wl, NewCertificationFilter(themoviedbAPI, "DE", "0"), 1)
+
+	assert.Nil(t, err)
+	assert.Len(t, rails, 1)
+	assert.Greater(t, concurrency.Max(), 0)
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
@@ -0,0 +1,362 @@
+// Package resolve looks up lists of tv show titles on themoviedb and picks the
+// best match for each of them, flagging matches that need a human review.
+package resolve
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// matches below this confidence are flagged as ambiguous
+const MinConfidence = 0.8
+
+// a match is ambiguous if the runner-up scores at most this much less
+const AmbiguityMargin = 0.05
+
+// number of alternatives reported for a match
+const maxCandidates = 3
+
+// a title to look up. Year is 0 if unknown
+type Query struct {
+	Line  int    `json:"line"`
+	Title string `json:"title"`
+	Year  int    `json:"year,omitempty"`
+}
+
+type Candidate struct {
+	TMDBID       int     `json:"tmdb_id"`
+	Name         string  `json:"name"`
+	FirstAirDate string  `json:"first_air_date"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// the result for one query. TMDBID is 0 if nothing was found
+type Match struct {
+	Query
+	Candidate
+	Ambiguous  bool        `json:"ambiguous"`
+	Candidates []Candidate `json:"candidates,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type Resolver struct {
+	client      *themoviedb.Client
+	concurrency int
+}
+
+// creates a resolver running at most concurrency searches at the same time
+func NewResolver(client *themoviedb.Client, concurrency int) *Resolver {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Resolver{client, concurrency}
+}
+
+// resolves all queries and returns the matches in the same order
+func (r *Resolver) Resolve(queries []Query) []Match {
+	matches := make([]Match, len(queries))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				matches[i] = r.resolve(queries[i])
+			}
+		}()
+	}
+
+	for i := range queries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return matches
+}
+
+func (r *Resolver) resolve(q Query) Match {
+	match := Match{Query: q}
+
+	results, err := r.client.SearchTVShows(q.Title, "1")
+	if err != nil {
+		match.Error = err.Error()
+		return match
+	}
+
+	candidates := make([]Candidate, 0, len(results.Results))
+	for _, show := range results.Results {
+		candidates = append(candidates, Candidate{
+			TMDBID:       show.ID,
+			Name:         show.Name,
+			FirstAirDate: show.FirstAirDate,
+			Confidence:   Score(q, show),
+		})
+	}
+
+	// themoviedb sorts by popularity which is the tie breaker for equal scores
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	if len(candidates) == 0 {
+		match.Ambiguous = true
+		return match
+	}
+
+	match.Candidate = candidates[0]
+	match.Ambiguous = candidates[0].Confidence < MinConfidence ||
+		len(candidates) > 1 && candidates[0].Confidence-candidates[1].Confidence <= AmbiguityMargin
+	if match.Ambiguous {
+		if len(candidates) > maxCandidates {
+			candidates = candidates[:maxCandidates]
+		}
+		match.Candidates = candidates
+	}
+	return match
+}
+
+// rates how well show matches q, from 0 (not at all) to 1 (same title and year)
+func Score(q Query, show themoviedb.TVShow) float64 {
+	title := normalize(q.Title)
+	score := similarity(title, normalize(show.Name))
+	if original := similarity(title, normalize(show.OriginalName)); original > score {
+		score = original
+	}
+
+	if q.Year == 0 {
+		return round(score)
+	}
+
+	year, err := strconv.Atoi(firstN(show.FirstAirDate, 4))
+	switch {
+	case err != nil:
+		score *= 0.8
+	case year == q.Year:
+	case year == q.Year-1 || year == q.Year+1:
+		score *= 0.9
+	default:
+		score *= 0.6
+	}
+	return round(score)
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// lowercases the title and drops punctuation and a leading article
+func normalize(title string) string {
+	title = strings.ToLower(strings.ReplaceAll(title, "&", " and "))
+	title = strings.TrimSpace(nonAlphanumeric.ReplaceAllString(title, " "))
+	return strings.TrimPrefix(title, "the ")
+}
+
+// similarity of a and b based on their levenshtein distance
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func round(f float64) float64 {
+	return float64(int(f*100+0.5)) / 100
+}
+
+func firstN(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
+
+// "Dark (2017)"
+var titleWithYear = regexp.MustCompile(`^(.*?)\s*\((\d{4})\)$`)
+
+// reads queries from r. format is "csv" for a csv with a title and an optional
+// year column, or "lines" for one title per line like "Dark (2017)"
+func ReadQueries(r io.Reader, format string) ([]Query, error) {
+	switch format {
+	case "csv":
+		return readCSV(r)
+	case "lines", "":
+		return readLines(r)
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+func readLines(r io.Reader) ([]Query, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []Query
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		q := Query{Line: i + 1, Title: line}
+		if m := titleWithYear.FindStringSubmatch(line); m != nil && m[1] != "" {
+			q.Title = m[1]
+			q.Year, _ = strconv.Atoi(m[2])
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func readCSV(r io.Reader) ([]Query, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	titleColumn, yearColumn, first := 0, 1, 0
+	if len(records) > 0 && isHeader(records[0]) {
+		yearColumn = -1
+		for i, column := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "title", "name":
+				titleColumn = i
+			case "year":
+				yearColumn = i
+			}
+		}
+		first = 1
+	}
+
+	var queries []Query
+	for i := first; i < len(records); i++ {
+		record := records[i]
+		if titleColumn >= len(record) || strings.TrimSpace(record[titleColumn]) == "" {
+			continue
+		}
+
+		q := Query{Line: i + 1, Title: strings.TrimSpace(record[titleColumn])}
+		if yearColumn >= 0 && yearColumn < len(record) && strings.TrimSpace(record[yearColumn]) != "" {
+			q.Year, err = strconv.Atoi(strings.TrimSpace(record[yearColumn]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid year %q", i+1, record[yearColumn])
+			}
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func isHeader(record []string) bool {
+	for _, column := range record {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "title", "name":
+			return true
+		}
+	}
+	return false
+}
+
+// writes the matches as csv, alternatives of ambiguous matches are joined in one column
+func WriteCSV(w io.Writer, matches []Match) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"line", "title", "year", "tmdb_id", "name", "first_air_date", "confidence", "ambiguous", "candidates", "error"})
+
+	for _, m := range matches {
+		candidates := make([]string, 0, len(m.Candidates))
+		for _, c := range m.Candidates {
+			candidates = append(candidates, fmt.Sprintf("%d:%s (%.2f)", c.TMDBID, c.Name, c.Confidence))
+		}
+
+		writer.Write([]string{
+			strconv.Itoa(m.Line),
+			m.Title,
+			optional(m.Year),
+			optional(m.TMDBID),
+			m.Name,
+			m.FirstAirDate,
+			strconv.FormatFloat(m.Confidence, 'f', 2, 64),
+			strconv.FormatBool(m.Ambiguous),
+			strings.Join(candidates, "; "),
+			m.Error,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writes the matches as a json array
+func WriteJSON(w io.Writer, matches []Match) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(matches)
+}
+
+func optional(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+// counts the matches whose lookup failed
+func Failed(matches []Match) int {
+	failed := 0
+	for _, m := range matches {
+		if m.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,135 @@
+package resolve
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLines(t *testing.T) {
+
+	input := "Dark (2017)\n\n# comment\nThe Office\n"
+
+	queries, err := ReadQueries(strings.NewReader(input), "lines")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Query{{Line: 1, Title: "Dark", Year: 2017}, {Line: 4, Title: "The Office"}}, queries)
+}
+
+func TestReadCSV(t *testing.T) {
+
+	queries, err := ReadQueries(strings.NewReader("year,title\n2017,Dark\n,The Office\n"), "csv")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Query{{Line: 2, Title: "Dark", Year: 2017}, {Line: 3, Title: "The Office"}}, queries)
+
+	queries, err = ReadQueries(strings.NewReader("Dark,2017\n"), "csv")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Query{{Line: 1, Title: "Dark", Year: 2017}}, queries, "csv without header should be title, year")
+
+	_, err = ReadQueries(strings.NewReader("title,year\nDark,soon\n"), "csv")
+	assert.NotNil(t, err, "invalid year should fail")
+}
+
+func TestScore(t *testing.T) {
+
+	dark := themoviedb.TVShow{Name: "Dark", FirstAirDate: "2017-12-01"}
+
+	assert.Equal(t, 1.0, Score(Query{Title: "dark"}, dark))
+	assert.Equal(t, 1.0, Score(Query{Title: "Dark", Year: 2017}, dark))
+	assert.Equal(t, 0.6, Score(Query{Title: "Dark", Year: 2005}, dark))
+	assert.Equal(t, 1.0, Score(Query{Title: "Office"}, themoviedb.TVShow{Name: "The Office"}))
+	assert.Equal(t, 1.0, Score(Query{Title: "Haus des Geldes"}, themoviedb.TVShow{Name: "Money Heist", OriginalName: "Haus des Geldes"}))
+	assert.True(t, Score(Query{Title: "Dark"}, themoviedb.TVShow{Name: "Dark Matter"}) < MinConfidence)
+}
+
+func TestResolve(t *testing.T) {
+
+	client := themoviedbtest.NewClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("query") {
+		case "Dark":
+			w.Write([]byte(`{"results":[{"id":1,"name":"Dark Matter","first_air_date":"2015-06-12"},{"id":70523,"name":"Dark","first_air_date":"2017-12-01"}]}`))
+		case "The Office":
+			w.Write([]byte(`{"results":[{"id":2316,"name":"The Office","first_air_date":"2005-03-24"},{"id":2996,"name":"The Office","first_air_date":"2001-07-09"}]}`))
+		case "Broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"results":[]}`))
+		}
+	})
+
+	matches := NewResolver(client, 2).Resolve([]Query{
+		{Line: 1, Title: "Dark", Year: 2017},
+		{Line: 2, Title: "The Office"},
+		{Line: 3, Title: "The Office", Year: 2001},
+		{Line: 4, Title: "Nothing"},
+		{Line: 5, Title: "Broken"},
+	})
+
+	assert.Equal(t, 70523, matches[0].TMDBID)
+	assert.False(t, matches[0].Ambiguous)
+
+	assert.True(t, matches[1].Ambiguous, "same title without year should be ambiguous")
+	assert.Len(t, matches[1].Candidates, 2)
+
+	assert.Equal(t, 2996, matches[2].TMDBID, "year should pick the right show")
+	assert.False(t, matches[2].Ambiguous)
+
+	assert.Equal(t, 0, matches[3].TMDBID)
+	assert.True(t, matches[3].Ambiguous)
+
+	assert.NotEmpty(t, matches[4].Error)
+	assert.Equal(t, 1, Failed(matches))
+}
+
+func TestResolveBoundsConcurrency(t *testing.T) {
+
+	var running, maxRunning int32
+	client := themoviedbtest.NewClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		w.Write([]byte(`{"results":[]}`))
+	})
+
+	queries := make([]Query, 20)
+	for i := range queries {
+		queries[i] = Query{Line: i + 1, Title: "Dark"}
+	}
+
+	matches := NewResolver(client, 3).Resolve(queries)
+
+	assert.Len(t, matches, 20)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(3))
+}
+
+func TestWriteCSV(t *testing.T) {
+
+	matches := []Match{
+		{Query: Query{Line: 1, Title: "Dark", Year: 2017}, Candidate: Candidate{TMDBID: 70523, Name: "Dark", FirstAirDate: "2017-12-01", Confidence: 1}},
+		{Query: Query{Line: 2, Title: "The Office"}, Candidate: Candidate{TMDBID: 2316, Name: "The Office", Confidence: 1}, Ambiguous: true,
+			Candidates: []Candidate{{TMDBID: 2316, Name: "The Office", Confidence: 1}, {TMDBID: 2996, Name: "The Office", Confidence: 1}}},
+	}
+
+	out := &bytes.Buffer{}
+	assert.Nil(t, WriteCSV(out, matches))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "1,Dark,2017,70523,Dark,2017-12-01,1.00,false,,", lines[1])
+	assert.Contains(t, lines[2], "2316:The Office (1.00); 2996:The Office (1.00)")
+}
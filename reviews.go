@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"bereths.com/netstar/ratings"
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+)
+
+// the votes on themoviedb next to the ratings and reviews of the team
+type Reviews struct {
+	Target      ratings.Target
+	TMDBAverage float64
+	TMDBCount   int
+	// false if there is no database for reviews
+	Enabled bool
+	Summary ratings.Summary
+	Reviews []ratings.Review
+	// the review of the current user
+	Mine *ratings.Review
+}
+
+// the range of ratings for the select of the form
+func (*Reviews) Scale() []int {
+	scale := []int{}
+	for i := ratings.MaxRating; i >= ratings.MinRating; i-- {
+		scale = append(scale, i)
+	}
+	return scale
+}
+
+func loadReviews(store *ratings.Store, r *http.Request, target ratings.Target, tmdbAverage float64, tmdbCount int) (*Reviews, error) {
+	reviews := &Reviews{Target: target, TMDBAverage: tmdbAverage, TMDBCount: tmdbCount}
+	if store == nil {
+		return reviews, nil
+	}
+
+	all, err := store.For(target)
+	if err != nil {
+		return nil, err
+	}
+	reviews.Enabled, reviews.Reviews, reviews.Summary = true, all, ratings.Summarize(all)
+
+	if user := users.CurrentUser(r); user != nil {
+		for i := range all {
+			if all[i].UserID == user.ID {
+				reviews.Mine = &all[i]
+			}
+		}
+	}
+	return reviews, nil
+}
+
+// rates a show, season or episode like kind=episode&id=70523&seasonNumber=1&episodeNumber=3&rating=9
+func ReviewHandler(themoviedbAPI *themoviedb.Client, store *ratings.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		target, err := formTarget(r)
+		if err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		rating, err := strconv.Atoi(r.PostFormValue("rating"))
+		if err != nil {
+			httpError(w, r, errInvalidRating, http.StatusBadRequest)
+			return
+		}
+
+		name, err := targetName(themoviedbAPI, target)
+		if err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		review := ratings.Review{
+			Target:   target,
+			UserID:   user.ID,
+			Username: user.Username,
+			Name:     name,
+			Rating:   rating,
+			Text:     r.PostFormValue("text"),
+		}
+		if err := store.Put(review); err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, target.Path(), http.StatusSeeOther)
+	}
+}
+
+// removes the review of the current user
+func DeleteReviewHandler(store *ratings.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		target, err := formTarget(r)
+		if err != nil {
+			httpError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := store.Delete(user.ID, target); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, target.Path(), http.StatusSeeOther)
+	}
+}
+
+// GET /api/ratings/top?kind=show&limit=10&min_votes=1, what the team liked best
+func APITopRatedHandler(store *ratings.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if users.CurrentUser(r) == nil {
+			writeJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		params := r.URL.Query()
+		kind := params.Get("kind")
+		if kind == "" {
+			kind = ratings.Show
+		}
+		if kind != ratings.Show && kind != ratings.Season && kind != ratings.Episode {
+			writeJSONError(w, http.StatusBadRequest, "kind must be show, season or episode")
+			return
+		}
+
+		limit, err := queryInt(params.Get("limit"), 10)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		minVotes, err := queryInt(params.Get("min_votes"), 1)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid min_votes")
+			return
+		}
+
+		top, err := store.Top(kind, minVotes, limit)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, top)
+	}
+}
+
+// the target of a review form, season and episode number are only read if the kind needs them
+func formTarget(r *http.Request) (ratings.Target, error) {
+	target := ratings.Target{Kind: r.PostFormValue("kind")}
+
+	names := []string{"id"}
+	switch target.Kind {
+	case ratings.Season:
+		names = append(names, "seasonNumber")
+	case ratings.Episode:
+		names = append(names, "seasonNumber", "episodeNumber")
+	}
+
+	ids, err := formInts(r, names...)
+	if err != nil {
+		return target, err
+	}
+	target.ShowID = ids[0]
+	if len(ids) > 1 {
+		target.Season = ids[1]
+	}
+	if len(ids) > 2 {
+		target.Episode = ids[2]
+	}
+
+	if !target.Valid() {
+		return target, ratings.ErrInvalidTarget
+	}
+	return target, nil
+}
+
+// a readable name of the target, like "Dark S01E03 Past and Present"
+func targetName(themoviedbAPI *themoviedb.Client, target ratings.Target) (string, error) {
+	id := strconv.Itoa(target.ShowID)
+	show, err := themoviedbAPI.GetTVShowDetails(id)
+	if err != nil {
+		return "", err
+	}
+
+	switch target.Kind {
+	case ratings.Season:
+		season, err := themoviedbAPI.GetSeasonDetails(id, strconv.Itoa(target.Season))
+		if err != nil {
+			return "", err
+		}
+		return show.Name + " " + season.Name, nil
+	case ratings.Episode:
+		episode, err := themoviedbAPI.GetEpisodeDetails(id, strconv.Itoa(target.Season), strconv.Itoa(target.Episode))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s S%02dE%02d %s", show.Name, target.Season, target.Episode, episode.Name), nil
+	}
+	return show.Name, nil
+}
+
+// reads a positive number from a query parameter
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"bereths.com/netstar/ratings"
+	"github.com/stretchr/testify/assert"
+)
+
+func GetMockReviewServer(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark","vote_average":8.4,"vote_count":5123}`))
+		case "/tv/70523/season/1/episode/3":
+			w.Write([]byte(`{"season_number":1,"episode_number":3,"name":"Past and Present","vote_average":7.9,"vote_count":42}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+func TestRateAndReview(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockReviewServer(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/details?id=70523")
+
+	app.Ratings.Put(ratings.Review{Target: ratings.Target{Kind: ratings.Show, ShowID: 70523}, UserID: 99, Username: "martha", Name: "Dark", Rating: 8})
+
+	resp, _ := client.PostForm(mockServer.URL+"/reviews", url.Values{"csrf_token": {token}, "kind": {"show"}, "id": {"70523"}, "rating": {"10"}, "text": {"Everything is connected"}})
+	body := ReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "8.4", "themoviedb vote average should be shown")
+	assert.Contains(t, body, "5123 votes")
+	assert.Contains(t, body, "9.0", "team average should be shown")
+	assert.Contains(t, body, "Everything is connected")
+	assert.Contains(t, body, "Update rating")
+
+	resp, _ = client.PostForm(mockServer.URL+"/reviews", url.Values{"csrf_token": {token}, "kind": {"episode"}, "id": {"70523"}, "seasonNumber": {"1"}, "episodeNumber": {"3"}, "rating": {"7"}})
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "7.9")
+	assert.Contains(t, body, "7.0")
+
+	resp, _ = client.PostForm(mockServer.URL+"/reviews", url.Values{"csrf_token": {token}, "kind": {"show"}, "id": {"70523"}, "rating": {"11"}})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = client.Get(mockServer.URL + "/api/ratings/top?kind=episode")
+	var top []ratings.Ranked
+	json.NewDecoder(resp.Body).Decode(&top)
+	resp.Body.Close()
+	if assert.Len(t, top, 1) {
+		assert.Equal(t, "Dark S01E03 Past and Present", top[0].Name)
+	}
+
+	resp, _ = client.PostForm(mockServer.URL+"/reviews/delete", url.Values{"csrf_token": {token}, "kind": {"show"}, "id": {"70523"}})
+	body = ReadBody(t, resp)
+	assert.NotContains(t, body, "Everything is connected")
+	assert.Contains(t, body, "8.0")
+}
+
+func TestTopRatedAPIWithInvalidKind(t *testing.T) {
+
+	mockServer, client, _ := GetAccountServer(t, GetValidClient())
+	LoginTestUser(t, mockServer, client)
+
+	resp, _ := client.Get(mockServer.URL + "/api/ratings/top?kind=movie")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
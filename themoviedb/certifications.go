@@ -0,0 +1,80 @@
+package themoviedb
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// the keywords themoviedb tagged a show with, like "time travel"
+type Keywords struct {
+	ID      int       `json:"id"`
+	Results []Keyword `json:"results"`
+}
+
+type Keyword struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// the age ratings of a show by country
+type ContentRatings struct {
+	ID      int             `json:"id"`
+	Results []ContentRating `json:"results"`
+}
+
+type ContentRating struct {
+	// the country, like DE
+	Iso31661 string `json:"iso_3166_1"`
+	// the certification, like "TV-MA" or "16"
+	Rating      string   `json:"rating"`
+	Descriptors []string `json:"descriptors"`
+}
+
+// the rating of the show in region, nil if it was not rated there
+func (c *ContentRatings) For(region string) *ContentRating {
+	for i, rating := range c.Results {
+		if rating.Iso31661 == region && rating.Rating != "" {
+			return &c.Results[i]
+		}
+	}
+	return nil
+}
+
+// the certifications shows can get by country
+type Certifications struct {
+	Certifications map[string][]Certification `json:"certifications"`
+}
+
+type Certification struct {
+	Certification string `json:"certification"`
+	Meaning       string `json:"meaning"`
+	// certifications with a higher order are meant for older audiences
+	Order int `json:"order"`
+}
+
+// the certifications of region, for the youngest audience first
+func (c *Certifications) Of(region string) []Certification {
+	certifications := append([]Certification(nil), c.Certifications[region]...)
+	sort.SliceStable(certifications, func(i, j int) bool { return certifications[i].Order < certifications[j].Order })
+	return certifications
+}
+
+func (c *Client) GetKeywords(id string) (*Keywords, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/keywords?api_key=%s", id, c.key)
+	log.Println(endpoint)
+	return SendRequest[Keywords](endpoint, c)
+}
+
+func (c *Client) GetContentRatings(id string) (*ContentRatings, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/content_ratings?api_key=%s", id, c.key)
+	log.Println(endpoint)
+	return SendRequest[ContentRatings](endpoint, c)
+}
+
+// the certifications of tv shows in all countries
+func (c *Client) GetTVCertifications() (*Certifications, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/certification/tv/list?api_key=%s", c.key)
+	log.Println(endpoint)
+	return SendRequest[Certifications](endpoint, c)
+}
@@ -0,0 +1,46 @@
+package themoviedb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeywordsAndContentRatings(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523/keywords":
+			w.Write([]byte(`{"id":70523,"results":[{"id":4379,"name":"time travel"},{"id":10714,"name":"serial killer"}]}`))
+		case "/tv/70523/content_ratings":
+			w.Write([]byte(`{"id":70523,"results":[{"iso_3166_1":"US","rating":"TV-MA","descriptors":["violence"]},{"iso_3166_1":"DE","rating":"16"},{"iso_3166_1":"FR","rating":""}]}`))
+		case "/certification/tv/list":
+			w.Write([]byte(`{"certifications":{"DE":[{"certification":"16","order":4},{"certification":"0","order":1},{"certification":"12","order":3}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	keywords, err := themoviedbAPI.GetKeywords("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, "time travel", keywords.Results[0].Name)
+
+	ratings, err := themoviedbAPI.GetContentRatings("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, "16", ratings.For("DE").Rating)
+	assert.Equal(t, []string{"violence"}, ratings.For("US").Descriptors)
+	assert.Nil(t, ratings.For("FR"), "empty ratings should count as not rated")
+	assert.Nil(t, ratings.For("GB"))
+
+	certifications, err := themoviedbAPI.GetTVCertifications()
+	assert.Nil(t, err)
+	german := certifications.Of("DE")
+	assert.Equal(t, []string{"0", "12", "16"}, []string{german[0].Certification, german[1].Certification, german[2].Certification})
+	assert.Empty(t, certifications.Of("XX"))
+}
@@ -0,0 +1,129 @@
+package themoviedb
+
+import (
+	"log"
+	"time"
+)
+
+// the texts of a show, season or episode that are missing in the language of the client and
+// were filled in from a fallback language, like "overview": "en-US"
+type Fallbacks map[string]string
+
+// the language the text of field was taken from, empty if it is in the language of the client
+func (f Fallbacks) Of(field string) string {
+	return f[field]
+}
+
+// sets text to fallback if it is empty and remembers the language it came from
+func (f *Fallbacks) fill(field string, text *string, fallback, lang string) {
+	if *text != "" || fallback == "" {
+		return
+	}
+	*text = fallback
+	if *f == nil {
+		*f = Fallbacks{}
+	}
+	(*f)[field] = lang
+}
+
+// the languages asked in order when names or overviews are missing in the language
+// of the client, like "en-US". none by default
+func (c *Client) SetFallbackLanguages(langs ...string) {
+	c.fallbacks = nil
+	for _, lang := range langs {
+		if lang != "" && lang != c.lang {
+			c.fallbacks = append(c.fallbacks, lang)
+		}
+	}
+}
+
+// the client asking themoviedb in lang without falling back
+func (c *Client) inLanguage(lang string) *Client {
+	fallback := *c
+	fallback.lang = lang
+	fallback.fallbacks = nil
+	return &fallback
+}
+
+func (c *Client) fillShow(id string, show *TVShowDetails) {
+	for _, lang := range c.fallbacks {
+		if !show.missesText() {
+			return
+		}
+		fallback, err := c.inLanguage(lang).GetTVShowDetails(id)
+		if err != nil {
+			log.Printf("Could not load show %s in %s: %v", id, lang, err)
+			continue
+		}
+		show.Fallbacks.fill("name", &show.Name, fallback.Name, lang)
+		show.Fallbacks.fill("overview", &show.Overview, fallback.Overview, lang)
+	}
+}
+
+// fills the season and the names and overviews of its episodes
+func (c *Client) fillSeason(id, seasonNumber string, season *TVSeasonDetails) {
+	for _, lang := range c.fallbacks {
+		if !season.missesText() {
+			return
+		}
+		fallback, err := c.inLanguage(lang).GetSeasonDetails(id, seasonNumber)
+		if err != nil {
+			log.Printf("Could not load season %s of %s in %s: %v", seasonNumber, id, lang, err)
+			continue
+		}
+		season.Fallbacks.fill("name", &season.Name, fallback.Name, lang)
+		season.Fallbacks.fill("overview", &season.Overview, fallback.Overview, lang)
+		for i := range season.Episodes {
+			episode := &season.Episodes[i]
+			for _, translated := range fallback.Episodes {
+				if translated.EpisodeNumber == episode.EpisodeNumber {
+					episode.Fallbacks.fill("name", &episode.Name, translated.Name, lang)
+					episode.Fallbacks.fill("overview", &episode.Overview, translated.Overview, lang)
+				}
+			}
+		}
+	}
+}
+
+// shows that have not aired yet rarely have an overview in any language, asking
+// the fallbacks for every announced show on a watchlist is not worth it
+func (s *TVShowDetails) missesText() bool {
+	return s.Name == "" || s.Overview == "" && aired(s.FirstAirDate)
+}
+
+func (s *TVSeasonDetails) missesText() bool {
+	if s.Name == "" || s.Overview == "" && aired(s.AirDate) {
+		return true
+	}
+	for _, episode := range s.Episodes {
+		if episodeMissesText(episode.Name, episode.Overview, episode.AirDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// episodes that have not aired yet have no name or overview in any language either
+func episodeMissesText(name, overview, airDate string) bool {
+	return (name == "" || overview == "") && aired(airDate)
+}
+
+// whether date, like 2017-12-01, is today or earlier
+func aired(date string) bool {
+	return date != "" && date <= time.Now().Format("2006-01-02")
+}
+
+func (c *Client) fillEpisode(id, seasonNumber, episodeNumber string, episode *TVEpisodeDetails) {
+	for _, lang := range c.fallbacks {
+		if !episodeMissesText(episode.Name, episode.Overview, episode.AirDate) {
+			return
+		}
+		fallback, err := c.inLanguage(lang).GetEpisodeDetails(id, seasonNumber, episodeNumber)
+		if err != nil {
+			log.Printf("Could not load episode %s/%s/%s in %s: %v", id, seasonNumber, episodeNumber, lang, err)
+			continue
+		}
+		episode.Fallbacks.fill("name", &episode.Name, fallback.Name, lang)
+		episode.Fallbacks.fill("overview", &episode.Overview, fallback.Overview, lang)
+	}
+}
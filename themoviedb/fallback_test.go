@@ -0,0 +1,111 @@
+package themoviedb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// counts the requests in other languages than de-DE in fallbacks
+func GetFallbackServer(t *testing.T, fallbacks *int32) *httptest.Server {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("language")
+		if lang != "de-DE" {
+			atomic.AddInt32(fallbacks, 1)
+		}
+		switch r.URL.Path + " " + lang {
+		case "/tv/70523 de-DE":
+			w.Write([]byte(`{"id":70523,"name":"Dark","overview":"","first_air_date":"2017-12-01"}`))
+		case "/tv/70523 fr-FR":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/tv/70523 en-US":
+			w.Write([]byte(`{"id":70523,"name":"Dark","overview":"A family saga with a supernatural twist."}`))
+		case "/tv/70523/season/1 de-DE":
+			w.Write([]byte(`{"id":1,"name":"Staffel 1","overview":"","air_date":"2017-12-01","season_number":1,"episodes":[{"episode_number":1,"air_date":"2017-12-01","name":"Geheimnisse","overview":""},{"episode_number":2,"air_date":"2017-12-01","name":"","overview":""}]}`))
+		case "/tv/70523/season/1 en-US":
+			w.Write([]byte(`{"id":1,"name":"Season 1","overview":"","season_number":1,"episodes":[{"episode_number":1,"name":"Secrets","overview":"Winden, 2019."},{"episode_number":2,"name":"Lies","overview":"Jonas has not told anyone."}]}`))
+		case "/tv/70523/season/1/episode/2 de-DE":
+			w.Write([]byte(`{"id":2,"name":"Lügen","overview":"","air_date":"2017-12-01"}`))
+		case "/tv/70523/season/4/episode/2 de-DE":
+			w.Write([]byte(`{"id":42,"name":"","overview":"","air_date":"2999-01-01"}`))
+		case "/tv/70523/season/1/episode/2 en-US":
+			w.Write([]byte(`{"id":2,"name":"Lies","overview":"Jonas has not told anyone."}`))
+		case "/tv/1 de-DE":
+			w.Write([]byte(`{"id":1,"name":"Angekündigt","overview":"","first_air_date":"2999-01-01"}`))
+		case "/tv/70523/season/4 de-DE":
+			w.Write([]byte(`{"id":4,"name":"Staffel 4","overview":"Das Ende.","air_date":"2020-06-27","season_number":4,"episodes":[{"episode_number":1,"air_date":"2020-06-27","name":"Anfang","overview":"Das Ende beginnt."},{"episode_number":2,"air_date":"2999-01-01","name":"","overview":""}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+	return mockServer
+}
+
+func TestFallbackLanguages(t *testing.T) {
+
+	themoviedbAPI := NewClient(http.DefaultClient, "1234", "de-DE", false)
+	var fallbacks int32
+	themoviedbAPI.SetBaseURL(GetFallbackServer(t, &fallbacks).URL)
+	themoviedbAPI.SetFallbackLanguages("de-DE", "fr-FR", "en-US")
+
+	show, err := themoviedbAPI.GetTVShowDetails("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, "A family saga with a supernatural twist.", show.Overview, "failing fallbacks should be skipped")
+	assert.Equal(t, "en-US", show.Fallbacks.Of("overview"))
+	assert.Equal(t, "", show.Fallbacks.Of("name"), "translated texts should be kept")
+
+	season, err := themoviedbAPI.GetSeasonDetails("70523", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Staffel 1", season.Name)
+	assert.Equal(t, "", season.Overview, "texts missing in every language should stay empty")
+	assert.Equal(t, "Geheimnisse", season.Episodes[0].Name)
+	assert.Equal(t, "Winden, 2019.", season.Episodes[0].Overview, "overviews of aired episodes should be filled")
+	assert.Equal(t, Fallbacks{"overview": "en-US"}, season.Episodes[0].Fallbacks)
+	assert.Equal(t, "Lies", season.Episodes[1].Name)
+	assert.Equal(t, "en-US", season.Episodes[1].Fallbacks.Of("name"))
+	assert.Equal(t, 70523, season.TVID)
+
+	episode, err := themoviedbAPI.GetEpisodeDetails("70523", "1", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "Lügen", episode.Name)
+	assert.Equal(t, "Jonas has not told anyone.", episode.Overview)
+	assert.Equal(t, Fallbacks{"overview": "en-US"}, episode.Fallbacks)
+}
+
+func TestWithoutFallbackLanguages(t *testing.T) {
+
+	themoviedbAPI := NewClient(http.DefaultClient, "1234", "de-DE", false)
+	var fallbacks int32
+	themoviedbAPI.SetBaseURL(GetFallbackServer(t, &fallbacks).URL)
+
+	show, err := themoviedbAPI.GetTVShowDetails("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, "", show.Overview)
+	assert.Nil(t, show.Fallbacks)
+}
+
+func TestFallbackOnlyForAiredTexts(t *testing.T) {
+
+	themoviedbAPI := NewClient(http.DefaultClient, "1234", "de-DE", false)
+	var fallbacks int32
+	themoviedbAPI.SetBaseURL(GetFallbackServer(t, &fallbacks).URL)
+	themoviedbAPI.SetFallbackLanguages("en-US")
+
+	show, err := themoviedbAPI.GetTVShowDetails("1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", show.Overview)
+
+	season, err := themoviedbAPI.GetSeasonDetails("70523", "4")
+	assert.Nil(t, err)
+	assert.Equal(t, "Anfang", season.Episodes[0].Name)
+	assert.Equal(t, "", season.Episodes[1].Name)
+
+	episode, err := themoviedbAPI.GetEpisodeDetails("70523", "4", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "", episode.Overview)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fallbacks), "texts of what has not aired should not be looked up")
+}
@@ -0,0 +1,119 @@
+package themoviedb
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// the kinds of images themoviedb has, they come in different sizes
+const (
+	PosterImage   = "poster"
+	BackdropImage = "backdrop"
+	LogoImage     = "logo"
+	StillImage    = "still"
+)
+
+// the widths themoviedb scales the images of a kind to, besides the original
+var imageWidths = map[string][]int{
+	PosterImage:   {92, 154, 185, 342, 500, 780},
+	BackdropImage: {300, 780, 1280},
+	LogoImage:     {45, 92, 154, 185, 300, 500},
+	StillImage:    {92, 185, 300},
+}
+
+// the images of a show, season or episode. seasons only have posters and episodes only stills
+type Images struct {
+	ID        int     `json:"id"`
+	Backdrops []Image `json:"backdrops"`
+	Logos     []Image `json:"logos"`
+	Posters   []Image `json:"posters"`
+	Stills    []Image `json:"stills"`
+}
+
+type Image struct {
+	AspectRatio float64 `json:"aspect_ratio"`
+	FilePath    string  `json:"file_path"`
+	Height      int     `json:"height"`
+	Width       int     `json:"width"`
+	// empty for images without text
+	Iso6391     string  `json:"iso_639_1"`
+	VoteAverage float64 `json:"vote_average"`
+	VoteCount   int     `json:"vote_count"`
+}
+
+// the url of the image at size, like "w500" or "original"
+func ImageURL(size, path string) string {
+	if path == "" {
+		return ""
+	}
+	return ImageBaseURL + "/" + size + path
+}
+
+// the srcset of an image of kind with all the widths themoviedb has, so browsers pick the smallest that fits.
+// the urls start with base, like ImageBaseURL or the path of an image proxy
+func SrcSet(base, kind, path string) string {
+	if path == "" {
+		return ""
+	}
+	sizes := make([]string, 0, len(imageWidths[kind]))
+	for _, width := range imageWidths[kind] {
+		sizes = append(sizes, fmt.Sprintf("%s/w%d%s %dw", base, width, path, width))
+	}
+	return strings.Join(sizes, ", ")
+}
+
+// the languages of the images, sorted. images without language are left out
+func (img *Images) Languages() []string {
+	seen := map[string]bool{}
+	for _, list := range [][]Image{img.Backdrops, img.Logos, img.Posters, img.Stills} {
+		for _, image := range list {
+			if image.Iso6391 != "" {
+				seen[image.Iso6391] = true
+			}
+		}
+	}
+	languages := make([]string, 0, len(seen))
+	for lang := range seen {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// the images in lang and the ones without language. an empty lang keeps all images
+func (img *Images) Filter(lang string) *Images {
+	if lang == "" {
+		return img
+	}
+	filter := func(images []Image) []Image {
+		filtered := []Image{}
+		for _, image := range images {
+			if image.Iso6391 == lang || image.Iso6391 == "" {
+				filtered = append(filtered, image)
+			}
+		}
+		return filtered
+	}
+	return &Images{ID: img.ID, Backdrops: filter(img.Backdrops), Logos: filter(img.Logos), Posters: filter(img.Posters), Stills: filter(img.Stills)}
+}
+
+// the images of the show in the language of the client, english and without language
+func (c *Client) GetTVShowImages(id string) (*Images, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/images?api_key=%s&include_image_language=%s", id, c.key, c.mediaLanguages())
+	log.Println(endpoint)
+	return SendRequest[Images](endpoint, c)
+}
+
+func (c *Client) GetSeasonImages(id string, seasonNumber string) (*Images, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s/images?api_key=%s&include_image_language=%s", id, seasonNumber, c.key, c.mediaLanguages())
+	log.Println(endpoint)
+	return SendRequest[Images](endpoint, c)
+}
+
+func (c *Client) GetEpisodeImages(id string, seasonNumber string, episodeNumber string) (*Images, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s/episode/%s/images?api_key=%s&include_image_language=%s", id, seasonNumber, episodeNumber, c.key, c.mediaLanguages())
+	log.Println(endpoint)
+	return SendRequest[Images](endpoint, c)
+}
@@ -0,0 +1,55 @@
+package themoviedb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSrcSet(t *testing.T) {
+
+	assert.Equal(t, "https://image.tmdb.org/t/p/w300/b.jpg 300w, https://image.tmdb.org/t/p/w780/b.jpg 780w, https://image.tmdb.org/t/p/w1280/b.jpg 1280w", SrcSet(ImageBaseURL, BackdropImage, "/b.jpg"))
+	assert.Equal(t, "", SrcSet(ImageBaseURL, PosterImage, ""), "missing images should have no srcset")
+	assert.Equal(t, "https://image.tmdb.org/t/p/w500/p.jpg", ImageURL("w500", "/p.jpg"))
+	assert.Contains(t, SrcSet("/img", PosterImage, "/p.jpg"), "/img/w92/p.jpg 92w")
+}
+
+func TestGetImages(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "de,en,null", r.URL.Query().Get("include_image_language"))
+		switch r.URL.Path {
+		case "/tv/70523/images":
+			w.Write([]byte(`{"id":70523,"backdrops":[{"file_path":"/b.jpg","iso_639_1":null,"width":1920,"height":1080}],
+				"posters":[{"file_path":"/de.jpg","iso_639_1":"de"},{"file_path":"/en.jpg","iso_639_1":"en"}],"logos":[]}`))
+		case "/tv/70523/season/1/images":
+			w.Write([]byte(`{"id":1,"posters":[{"file_path":"/s1.jpg","iso_639_1":"en"}]}`))
+		case "/tv/70523/season/1/episode/1/images":
+			w.Write([]byte(`{"id":2,"stills":[{"file_path":"/e1.jpg","iso_639_1":null}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	images, err := themoviedbAPI.GetTVShowImages("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"de", "en"}, images.Languages())
+	german := images.Filter("de")
+	assert.Len(t, german.Posters, 1)
+	assert.Len(t, german.Backdrops, 1, "images without language should always be kept")
+	assert.Len(t, images.Filter("").Posters, 2)
+
+	images, err = themoviedbAPI.GetSeasonImages("70523", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "/s1.jpg", images.Posters[0].FilePath)
+
+	images, err = themoviedbAPI.GetEpisodeImages("70523", "1", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "/e1.jpg", images.Stills[0].FilePath)
+}
@@ -0,0 +1,27 @@
+package themoviedb
+
+// what a single request asks themoviedb for, instead of the settings the client was created with
+type Options struct {
+	// like de-DE, empty keeps the language of the client
+	Language     string
+	IncludeAdult bool
+}
+
+// a copy of the client asking with opts. the fallback languages are kept
+func (c *Client) With(opts Options) *Client {
+	client := *c
+	if opts.Language != "" {
+		client.lang = opts.Language
+	}
+	client.includeAdult = opts.IncludeAdult
+	client.SetFallbackLanguages(c.fallbacks...)
+	return &client
+}
+
+func (c *Client) Language() string {
+	return c.lang
+}
+
+func (c *Client) IncludeAdult() bool {
+	return c.includeAdult
+}
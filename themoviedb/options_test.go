@@ -0,0 +1,33 @@
+package themoviedb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOptions(t *testing.T) {
+
+	var queries []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("language")+" "+r.URL.Query().Get("include_adult"))
+		w.Write([]byte(`{"page":1,"results":[]}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := NewClient(http.DefaultClient, "1234", "de-DE", false)
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+	themoviedbAPI.SetFallbackLanguages("en-US")
+
+	english := themoviedbAPI.With(Options{Language: "en-US", IncludeAdult: true})
+	english.SearchTVShows("Dark", "1")
+	themoviedbAPI.SearchTVShows("Dark", "1")
+
+	assert.Equal(t, []string{"en-US true", "de-DE false"}, queries, "the original client should keep its settings")
+	assert.Equal(t, "en-US", english.Language())
+	assert.True(t, english.IncludeAdult())
+	assert.Empty(t, english.fallbacks, "the language of the client should not be its own fallback")
+	assert.Equal(t, "de-DE", themoviedbAPI.With(Options{}).Language())
+}
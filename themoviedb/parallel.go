@@ -0,0 +1,23 @@
+package themoviedb
+
+import "sync"
+
+// the most requests a fan out over many shows sends to themoviedb at the same time
+const MaxConcurrentRequests = 8
+
+// calls fn with every index below n, at most MaxConcurrentRequests at the same time,
+// and returns when all calls returned
+func Parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, MaxConcurrentRequests)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,30 @@
+package themoviedb
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel(t *testing.T) {
+
+	var running, maxRunning, calls int32
+	Parallel(30, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+	})
+
+	assert.Equal(t, int32(30), calls)
+	assert.Greater(t, maxRunning, int32(1))
+	assert.LessOrEqual(t, maxRunning, int32(MaxConcurrentRequests))
+}
@@ -2,21 +2,35 @@ package themoviedb
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // to generate your structs from a json you can just use https://mholt.github.io/json-to-go/ !
 
 type Client struct {
 	http         *http.Client
+	baseURL      string
 	key          string
 	lang         string
 	includeAdult bool
+	// asked when texts are missing in lang, see SetFallbackLanguages
+	fallbacks []string
+}
+
+// APIError is returned whenever themoviedb answers with a status other than 200
+type APIError struct {
+	HTTPStatus    int    `json:"-"`
+	StatusCode    int    `json:"status_code"`
+	StatusMessage string `json:"status_message"`
+	body          string
 }
 
 type TVShow struct {
@@ -50,25 +64,14 @@ type TVShowDetails struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"genres"`
-	Homepage         string   `json:"homepage"`
-	ID               int      `json:"id"`
-	InProduction     bool     `json:"in_production"`
-	Languages        []string `json:"languages"`
-	LastAirDate      string   `json:"last_air_date"`
-	LastEpisodeToAir struct {
-		AirDate        string  `json:"air_date"`
-		EpisodeNumber  int     `json:"episode_number"`
-		ID             int     `json:"id"`
-		Name           string  `json:"name"`
-		Overview       string  `json:"overview"`
-		ProductionCode string  `json:"production_code"`
-		SeasonNumber   int     `json:"season_number"`
-		StillPath      string  `json:"still_path"`
-		VoteAverage    float64 `json:"vote_average"`
-		VoteCount      int     `json:"vote_count"`
-	} `json:"last_episode_to_air"`
-	Name             string      `json:"name"`
-	NextEpisodeToAir interface{} `json:"next_episode_to_air"`
+	Homepage         string        `json:"homepage"`
+	ID               int           `json:"id"`
+	InProduction     bool          `json:"in_production"`
+	Languages        []string      `json:"languages"`
+	LastAirDate      string        `json:"last_air_date"`
+	LastEpisodeToAir EpisodeToAir  `json:"last_episode_to_air"`
+	Name             string        `json:"name"`
+	NextEpisodeToAir *EpisodeToAir `json:"next_episode_to_air"`
 	Networks         []struct {
 		Name          string `json:"name"`
 		ID            int    `json:"id"`
@@ -112,6 +115,25 @@ type TVShowDetails struct {
 	Type        string  `json:"type"`
 	VoteAverage float64 `json:"vote_average"`
 	VoteCount   int     `json:"vote_count"`
+	// appended to the details by GetTVShowDetails
+	ExternalIDs ExternalIDs `json:"external_ids"`
+	// texts taken from a fallback language
+	Fallbacks Fallbacks `json:"fallbacks,omitempty"`
+}
+
+// the last aired or the next episode of a show. themoviedb sends null for
+// the next episode if none is announced
+type EpisodeToAir struct {
+	AirDate        string  `json:"air_date"`
+	EpisodeNumber  int     `json:"episode_number"`
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Overview       string  `json:"overview"`
+	ProductionCode string  `json:"production_code"`
+	SeasonNumber   int     `json:"season_number"`
+	StillPath      string  `json:"still_path"`
+	VoteAverage    float64 `json:"vote_average"`
+	VoteCount      int     `json:"vote_count"`
 }
 
 type TVSeasonDetails struct {
@@ -153,6 +175,8 @@ type TVSeasonDetails struct {
 		StillPath      string  `json:"still_path"`
 		VoteAverage    float64 `json:"vote_average"`
 		VoteCount      int     `json:"vote_count"`
+		// texts taken from a fallback language
+		Fallbacks Fallbacks `json:"fallbacks,omitempty"`
 	} `json:"episodes"`
 	Name         string `json:"name"`
 	Overview     string `json:"overview"`
@@ -160,6 +184,8 @@ type TVSeasonDetails struct {
 	PosterPath   string `json:"poster_path"`
 	SeasonNumber int    `json:"season_number"`
 	TVID         int
+	// texts taken from a fallback language
+	Fallbacks Fallbacks `json:"fallbacks,omitempty"`
 }
 
 type TVEpisodeDetails struct {
@@ -189,6 +215,155 @@ type TVEpisodeDetails struct {
 	StillPath      string  `json:"still_path"`
 	VoteAverage    float64 `json:"vote_average"`
 	VoteCount      int     `json:"vote_count"`
+	// appended to the details by GetEpisodeDetails
+	ExternalIDs ExternalIDs `json:"external_ids"`
+	// texts taken from a fallback language
+	Fallbacks Fallbacks `json:"fallbacks,omitempty"`
+}
+
+// the ids of a show or episode in other databases. themoviedb sends null for unknown ids
+type ExternalIDs struct {
+	IMDbID      string `json:"imdb_id"`
+	TVDBID      int    `json:"tvdb_id"`
+	WikidataID  string `json:"wikidata_id"`
+	TVRageID    int    `json:"tvrage_id"`
+	FreebaseMID string `json:"freebase_mid"`
+	FacebookID  string `json:"facebook_id"`
+	InstagramID string `json:"instagram_id"`
+	TwitterID   string `json:"twitter_id"`
+}
+
+func (ids ExternalIDs) IMDbURL() string {
+	if ids.IMDbID == "" {
+		return ""
+	}
+	return "https://www.imdb.com/title/" + url.PathEscape(ids.IMDbID) + "/"
+}
+
+func (ids ExternalIDs) WikidataURL() string {
+	if ids.WikidataID == "" {
+		return ""
+	}
+	return "https://www.wikidata.org/wiki/" + url.PathEscape(ids.WikidataID)
+}
+
+// the page of the show on thetvdb, episodes use TVDBEpisodeURL
+func (ids ExternalIDs) TVDBSeriesURL() string {
+	if ids.TVDBID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://thetvdb.com/dereferrer/series/%d", ids.TVDBID)
+}
+
+func (ids ExternalIDs) TVDBEpisodeURL() string {
+	if ids.TVDBID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://thetvdb.com/dereferrer/episode/%d", ids.TVDBID)
+}
+
+// where a show can be watched, by country code like DE. themoviedb gets these from JustWatch
+type WatchProviders struct {
+	ID      int                        `json:"id"`
+	Results map[string]RegionProviders `json:"results"`
+}
+
+// the providers of a country, grouped by how the show is offered
+type RegionProviders struct {
+	// the JustWatch page of the show
+	Link     string     `json:"link"`
+	Flatrate []Provider `json:"flatrate"`
+	Free     []Provider `json:"free"`
+	Ads      []Provider `json:"ads"`
+	Rent     []Provider `json:"rent"`
+	Buy      []Provider `json:"buy"`
+}
+
+type Provider struct {
+	ProviderID      int    `json:"provider_id"`
+	ProviderName    string `json:"provider_name"`
+	LogoPath        string `json:"logo_path"`
+	DisplayPriority int    `json:"display_priority"`
+}
+
+// the countries with providers, sorted
+func (p *WatchProviders) Regions() []string {
+	regions := make([]string, 0, len(p.Results))
+	for region := range p.Results {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// the videos of a show or season, GetTVShowVideos sorts them by language
+type Videos struct {
+	ID      int     `json:"id"`
+	Results []Video `json:"results"`
+}
+
+type Video struct {
+	ID          string `json:"id"`
+	Iso6391     string `json:"iso_639_1"`
+	Iso31661    string `json:"iso_3166_1"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Site        string `json:"site"`
+	Size        int    `json:"size"`
+	Type        string `json:"type"`
+	Official    bool   `json:"official"`
+	PublishedAt string `json:"published_at"`
+}
+
+// the kinds of videos worth showing on a details page, in the order they are shown
+var trailerTypes = []string{"Trailer", "Teaser", "Featurette"}
+
+// the url of the player of the video, empty if the site is unknown. youtube videos
+// are embedded from youtube-nocookie.com, which sets no cookies until they are played
+func (v Video) EmbedURL() string {
+	switch v.Site {
+	case "YouTube":
+		return "https://www.youtube-nocookie.com/embed/" + url.PathEscape(v.Key) + "?autoplay=1"
+	case "Vimeo":
+		return "https://player.vimeo.com/video/" + url.PathEscape(v.Key) + "?autoplay=1"
+	}
+	return ""
+}
+
+// the trailers, teasers and featurettes that can be embedded. the language order of
+// the results is kept, within a language trailers come first and official videos before others
+func (v *Videos) Trailers() []Video {
+	rank := func(video Video) int {
+		for i, t := range trailerTypes {
+			if video.Type == t {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// languages keep the position they first appear at
+	languages := map[string]int{}
+	trailers := []Video{}
+	for _, video := range v.Results {
+		if rank(video) >= 0 && video.EmbedURL() != "" {
+			if _, ok := languages[video.Iso6391]; !ok {
+				languages[video.Iso6391] = len(languages)
+			}
+			trailers = append(trailers, video)
+		}
+	}
+	sort.SliceStable(trailers, func(i, j int) bool {
+		a, b := trailers[i], trailers[j]
+		if languages[a.Iso6391] != languages[b.Iso6391] {
+			return languages[a.Iso6391] < languages[b.Iso6391]
+		}
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		return a.Official && !b.Official
+	})
+	return trailers
 }
 
 type Results struct {
@@ -198,12 +373,42 @@ type Results struct {
 	TotalResults int      `json:"total_results"`
 }
 
+// what themoviedb knows under the id of another database like imdb
+type FindResults struct {
+	TVResults       []TVShow `json:"tv_results"`
+	TVSeasonResults []struct {
+		ID           int    `json:"id"`
+		Name         string `json:"name"`
+		AirDate      string `json:"air_date"`
+		SeasonNumber int    `json:"season_number"`
+		ShowID       int    `json:"show_id"`
+	} `json:"tv_season_results"`
+	TVEpisodeResults []struct {
+		ID            int    `json:"id"`
+		Name          string `json:"name"`
+		AirDate       string `json:"air_date"`
+		EpisodeNumber int    `json:"episode_number"`
+		SeasonNumber  int    `json:"season_number"`
+		ShowID        int    `json:"show_id"`
+	} `json:"tv_episode_results"`
+}
+
 type Result interface {
-	Results | TVShowDetails | TVSeasonDetails | TVEpisodeDetails
+	Results | TVShowDetails | TVSeasonDetails | TVEpisodeDetails | FindResults | ExternalIDs | WatchProviders | Videos | Images | Keywords | ContentRatings | Certifications
 }
 
+// the external sources Find looks ids up in
+const (
+	IMDbID     = "imdb_id"
+	TVDBID     = "tvdb_id"
+	WikidataID = "wikidata_id"
+)
+
 var apiURL = "https://api.themoviedb.org/3"
 
+// base url of the images themoviedb references by path, followed by a size like "original"
+const ImageBaseURL = "https://image.tmdb.org/t/p"
+
 // creates a new client to use. if lang is empty default language will be en-US
 func NewClient(httpClient *http.Client, key string, lang string, includeAdult bool) *Client {
 	if lang == "" {
@@ -211,23 +416,50 @@ func NewClient(httpClient *http.Client, key string, lang string, includeAdult bo
 	}
 	log.Printf("Created new client %v %v %v ", key, lang, includeAdult)
 
-	return &Client{httpClient, key, lang, includeAdult}
+	return &Client{http: httpClient, baseURL: apiURL, key: key, lang: lang, includeAdult: includeAdult}
+}
+
+// sets the base url of the api, e.g. to talk to a mock server in tests
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+func (e *APIError) Error() string {
+	if e.StatusMessage != "" {
+		return e.StatusMessage
+	}
+	if e.body == "" {
+		return fmt.Sprintf("themoviedb answered with %d %s", e.HTTPStatus, http.StatusText(e.HTTPStatus))
+	}
+	return e.body
+}
+
+// returns true if err is an APIError telling us the requested resource does not exist
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusNotFound
 }
 
 func (c *Client) SearchTVShows(query, page string) (*Results, error) {
-	endpoint := fmt.Sprintf(apiURL+"/search/tv?query=%s&api_key=%s&language=%s&page=%s&include_adult=%s", url.QueryEscape(query), c.key, c.lang, page, strconv.FormatBool(c.includeAdult))
+	endpoint := fmt.Sprintf(c.baseURL+"/search/tv?query=%s&api_key=%s&language=%s&page=%s&include_adult=%s", url.QueryEscape(query), c.key, c.lang, page, strconv.FormatBool(c.includeAdult))
 	log.Println(endpoint)
 	return SendRequest[Results](endpoint, c)
 }
 
+// the details of the show. names and overviews missing in the language of the client are taken
+// from the fallback languages
 func (c *Client) GetTVShowDetails(id string) (*TVShowDetails, error) {
-	endpoint := fmt.Sprintf(apiURL+"/tv/%s?api_key=%s&language=%s", id, c.key, c.lang)
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s?api_key=%s&language=%s&append_to_response=external_ids", id, c.key, c.lang)
 	log.Println(endpoint)
-	return SendRequest[TVShowDetails](endpoint, c)
+	details, err := SendRequest[TVShowDetails](endpoint, c)
+	if err == nil {
+		c.fillShow(id, details)
+	}
+	return details, err
 }
 
 func (c *Client) GetSeasonDetails(id string, seasonNumber string) (*TVSeasonDetails, error) {
-	endpoint := fmt.Sprintf(apiURL+"/tv/%s/season/%s?api_key=%s&language=%s", id, seasonNumber, c.key, c.lang)
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s?api_key=%s&language=%s", id, seasonNumber, c.key, c.lang)
 	log.Println(endpoint)
 	details, error := SendRequest[TVSeasonDetails](endpoint, c)
 	if details != nil {
@@ -236,13 +468,101 @@ func (c *Client) GetSeasonDetails(id string, seasonNumber string) (*TVSeasonDeta
 			details.TVID = intID
 		}
 	}
+	if error == nil {
+		c.fillSeason(id, seasonNumber, details)
+	}
 	return details, error
 }
 
 func (c *Client) GetEpisodeDetails(id string, seasonNumber string, episodeNumber string) (*TVEpisodeDetails, error) {
-	endpoint := fmt.Sprintf(apiURL+"/tv/%s/season/%s/episode/%s?api_key=%s&language=%s", id, seasonNumber, episodeNumber, c.key, c.lang)
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s/episode/%s?api_key=%s&language=%s&append_to_response=external_ids", id, seasonNumber, episodeNumber, c.key, c.lang)
+	log.Println(endpoint)
+	details, err := SendRequest[TVEpisodeDetails](endpoint, c)
+	if err == nil {
+		c.fillEpisode(id, seasonNumber, episodeNumber, details)
+	}
+	return details, err
+}
+
+// the ids of the show in other databases, GetTVShowDetails already includes them
+func (c *Client) GetTVShowExternalIDs(id string) (*ExternalIDs, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/external_ids?api_key=%s", id, c.key)
+	log.Println(endpoint)
+	return SendRequest[ExternalIDs](endpoint, c)
+}
+
+func (c *Client) GetEpisodeExternalIDs(id string, seasonNumber string, episodeNumber string) (*ExternalIDs, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s/episode/%s/external_ids?api_key=%s", id, seasonNumber, episodeNumber, c.key)
+	log.Println(endpoint)
+	return SendRequest[ExternalIDs](endpoint, c)
+}
+
+// looks up an id of another database, like Find("tt5753856", IMDbID)
+func (c *Client) Find(externalID, source string) (*FindResults, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/find/%s?api_key=%s&language=%s&external_source=%s", url.PathEscape(externalID), c.key, c.lang, source)
+	log.Println(endpoint)
+	return SendRequest[FindResults](endpoint, c)
+}
+
+// where the show can be streamed, rented or bought in every country
+func (c *Client) GetWatchProviders(id string) (*WatchProviders, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/watch/providers?api_key=%s", id, c.key)
+	log.Println(endpoint)
+	return SendRequest[WatchProviders](endpoint, c)
+}
+
+// the shows themoviedb recommends to people who liked the show
+func (c *Client) GetRecommendations(id, page string) (*Results, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/recommendations?api_key=%s&language=%s&page=%s", id, c.key, c.lang, page)
+	log.Println(endpoint)
+	return SendRequest[Results](endpoint, c)
+}
+
+// the shows with similar genres and keywords
+func (c *Client) GetSimilarTVShows(id, page string) (*Results, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/similar?api_key=%s&language=%s&page=%s", id, c.key, c.lang, page)
+	log.Println(endpoint)
+	return SendRequest[Results](endpoint, c)
+}
+
+// the videos of the show in the language of the client, then english, then any other
+func (c *Client) GetTVShowVideos(id string) (*Videos, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/videos?api_key=%s&language=%s&include_video_language=%s", id, c.key, c.lang, c.mediaLanguages())
+	log.Println(endpoint)
+	return c.sortVideos(SendRequest[Videos](endpoint, c))
+}
+
+func (c *Client) GetSeasonVideos(id string, seasonNumber string) (*Videos, error) {
+	endpoint := fmt.Sprintf(c.baseURL+"/tv/%s/season/%s/videos?api_key=%s&language=%s&include_video_language=%s", id, seasonNumber, c.key, c.lang, c.mediaLanguages())
 	log.Println(endpoint)
-	return SendRequest[TVEpisodeDetails](endpoint, c)
+	return c.sortVideos(SendRequest[Videos](endpoint, c))
+}
+
+// the languages videos and images are requested in, like "de,en,null". null are the ones without language
+func (c *Client) mediaLanguages() string {
+	lang, _, _ := strings.Cut(c.lang, "-")
+	if lang == "en" {
+		return "en,null"
+	}
+	return lang + ",en,null"
+}
+
+// orders the videos by the languages of mediaLanguages, themoviedb returns them mixed
+func (c *Client) sortVideos(videos *Videos, err error) (*Videos, error) {
+	if err != nil {
+		return videos, err
+	}
+	order := strings.Split(c.mediaLanguages(), ",")
+	rank := func(video Video) int {
+		for i, lang := range order {
+			if video.Iso6391 == lang || video.Iso6391 == "" && lang == "null" {
+				return i
+			}
+		}
+		return len(order)
+	}
+	sort.SliceStable(videos.Results, func(i, j int) bool { return rank(videos.Results[i]) < rank(videos.Results[j]) })
+	return videos, nil
 }
 
 // Generic function to send a simple get request and get a result of T.
@@ -273,7 +593,9 @@ func GetResponse(c *Client, endpoint string) ([]byte, bool, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, true, fmt.Errorf(string(body))
+		apiErr := &APIError{HTTPStatus: resp.StatusCode, body: string(body)}
+		json.Unmarshal(body, apiErr)
+		return nil, true, apiErr
 	}
 	return body, false, nil
 }
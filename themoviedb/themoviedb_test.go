@@ -1,7 +1,10 @@
 package themoviedb
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -199,3 +202,203 @@ func TestGetEpisodeDetailsWithValidClientAndSearch(t *testing.T) {
 	assert.Equal(t, result.Name, "Der Winter naht")
 
 }
+
+func TestAPIErrorIsNotFound(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"status_code":34,"status_message":"The resource you requested could not be found."}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	_, err := themoviedbAPI.GetTVShowDetails("0")
+
+	assert.True(t, IsNotFound(err), "404 should be reported as not found")
+	assert.Equal(t, "The resource you requested could not be found.", err.Error())
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 34, apiErr.StatusCode)
+	assert.False(t, IsNotFound(fmt.Errorf("some other error")))
+}
+
+func TestNextEpisodeToAir(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tv/70523" {
+			w.Write([]byte(`{"id":70523,"name":"Dark","next_episode_to_air":null}`))
+			return
+		}
+		w.Write([]byte(`{"id":1,"name":"Running","next_episode_to_air":{"air_date":"2022-05-01","season_number":2,"episode_number":4,"name":"Next"}}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	result, err := themoviedbAPI.GetTVShowDetails("70523")
+	assert.Nil(t, err)
+	assert.Nil(t, result.NextEpisodeToAir, "ended shows have no next episode")
+
+	result, err = themoviedbAPI.GetTVShowDetails("1")
+	assert.Nil(t, err)
+	if assert.NotNil(t, result.NextEpisodeToAir) {
+		assert.Equal(t, "2022-05-01", result.NextEpisodeToAir.AirDate)
+		assert.Equal(t, 2, result.NextEpisodeToAir.SeasonNumber)
+		assert.Equal(t, 4, result.NextEpisodeToAir.EpisodeNumber)
+	}
+}
+
+func TestFind(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/find/tt5753856", r.URL.Path)
+		assert.Equal(t, IMDbID, r.URL.Query().Get("external_source"))
+		w.Write([]byte(`{"movie_results":[],"tv_results":[{"id":70523,"name":"Dark"}],"tv_episode_results":[],"tv_season_results":[]}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	result, err := themoviedbAPI.Find("tt5753856", IMDbID)
+
+	assert.Nil(t, err)
+	if assert.Len(t, result.TVResults, 1) {
+		assert.Equal(t, 70523, result.TVResults[0].ID)
+	}
+}
+
+func TestExternalIDs(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			assert.Equal(t, "external_ids", r.URL.Query().Get("append_to_response"))
+			w.Write([]byte(`{"id":70523,"name":"Dark","external_ids":{"imdb_id":"tt5753856","tvdb_id":334824,"wikidata_id":"Q30894592","tvrage_id":null}}`))
+		case "/tv/70523/season/1/episode/1/external_ids":
+			w.Write([]byte(`{"id":1,"imdb_id":"tt6305578","tvdb_id":6384958,"wikidata_id":null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	show, err := themoviedbAPI.GetTVShowDetails("70523")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://www.imdb.com/title/tt5753856/", show.ExternalIDs.IMDbURL())
+	assert.Equal(t, "https://thetvdb.com/dereferrer/series/334824", show.ExternalIDs.TVDBSeriesURL())
+	assert.Equal(t, "https://www.wikidata.org/wiki/Q30894592", show.ExternalIDs.WikidataURL())
+
+	ids, err := themoviedbAPI.GetEpisodeExternalIDs("70523", "1", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "tt6305578", ids.IMDbID)
+	assert.Equal(t, "https://thetvdb.com/dereferrer/episode/6384958", ids.TVDBEpisodeURL())
+	assert.Equal(t, "", ids.WikidataURL(), "unknown ids should have no url")
+
+	_, err = themoviedbAPI.GetTVShowExternalIDs("1")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestGetWatchProviders(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tv/70523/watch/providers", r.URL.Path)
+		w.Write([]byte(`{"id":70523,"results":{
+			"US":{"link":"https://www.themoviedb.org/tv/70523/watch?locale=US","flatrate":[{"provider_id":8,"provider_name":"Netflix","logo_path":"/netflix.jpg","display_priority":0}]},
+			"DE":{"link":"https://www.themoviedb.org/tv/70523/watch?locale=DE","flatrate":[{"provider_id":8,"provider_name":"Netflix"}],"buy":[{"provider_id":2,"provider_name":"Apple iTunes"}]}}}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	providers, err := themoviedbAPI.GetWatchProviders("70523")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"DE", "US"}, providers.Regions())
+	assert.Equal(t, "Apple iTunes", providers.Results["DE"].Buy[0].ProviderName)
+	assert.Equal(t, "/netflix.jpg", providers.Results["US"].Flatrate[0].LogoPath)
+}
+
+func TestGetRecommendationsAndSimilar(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		switch r.URL.Path {
+		case "/tv/70523/recommendations":
+			w.Write([]byte(`{"page":2,"results":[{"id":1,"name":"1899"}],"total_pages":2,"total_results":21}`))
+		case "/tv/70523/similar":
+			w.Write([]byte(`{"page":2,"results":[{"id":2,"name":"Stranger Things"}],"total_pages":5,"total_results":100}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	recommendations, err := themoviedbAPI.GetRecommendations("70523", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "1899", recommendations.Results[0].Name)
+
+	similar, err := themoviedbAPI.GetSimilarTVShows("70523", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, 100, similar.TotalResults)
+	assert.Equal(t, "Stranger Things", similar.Results[0].Name)
+}
+
+func TestGetTVShowVideos(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tv/70523/videos", r.URL.Path)
+		assert.Equal(t, "de,en,null", r.URL.Query().Get("include_video_language"))
+		w.Write([]byte(`{"id":70523,"results":[
+			{"iso_639_1":"en","key":"en-teaser","name":"Teaser","site":"YouTube","type":"Teaser","official":true},
+			{"iso_639_1":"en","key":"en-trailer","name":"Trailer","site":"YouTube","type":"Trailer","official":true},
+			{"iso_639_1":"de","key":"de-clip","name":"Clip","site":"YouTube","type":"Clip"},
+			{"iso_639_1":"de","key":"de-fan","name":"Fan Trailer","site":"Vimeo","type":"Trailer","official":false},
+			{"iso_639_1":"de","key":"de-trailer","name":"Offizieller Trailer","site":"YouTube","type":"Trailer","official":true},
+			{"iso_639_1":"de","key":"de-other","name":"Elsewhere","site":"Dailymotion","type":"Trailer"}]}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	videos, err := themoviedbAPI.GetTVShowVideos("70523")
+	assert.Nil(t, err)
+
+	keys := []string{}
+	for _, video := range videos.Trailers() {
+		keys = append(keys, video.Key)
+	}
+	assert.Equal(t, []string{"de-trailer", "de-fan", "en-trailer", "en-teaser"}, keys, "german videos should come first, then english")
+	assert.Equal(t, "https://www.youtube-nocookie.com/embed/de-trailer?autoplay=1", videos.Trailers()[0].EmbedURL())
+	assert.Equal(t, "https://player.vimeo.com/video/de-fan?autoplay=1", videos.Trailers()[1].EmbedURL())
+}
+
+func TestGetSeasonVideosInEnglish(t *testing.T) {
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tv/70523/season/1/videos", r.URL.Path)
+		assert.Equal(t, "en,null", r.URL.Query().Get("include_video_language"))
+		w.Write([]byte(`{"id":1,"results":[{"iso_639_1":"","key":"any","site":"YouTube","type":"Featurette"},{"iso_639_1":"en","key":"en","site":"YouTube","type":"Featurette"}]}`))
+	}))
+	defer mockServer.Close()
+
+	themoviedbAPI := NewClient(&http.Client{Timeout: time.Second}, "1234", "en-US", false)
+	themoviedbAPI.SetBaseURL(mockServer.URL)
+
+	videos, err := themoviedbAPI.GetSeasonVideos("70523", "1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "en", videos.Results[0].Key, "videos without language should come last")
+}
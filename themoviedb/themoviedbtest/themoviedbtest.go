@@ -0,0 +1,49 @@
+// Package themoviedbtest provides fakes of themoviedb for the tests of the packages using it.
+package themoviedbtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+)
+
+// a client asking handler instead of themoviedb, in english and without adult shows.
+// the server is closed when the test ends
+func NewClient(t *testing.T, handler http.HandlerFunc) *themoviedb.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := themoviedb.NewClient(&http.Client{Timeout: 10 * time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(server.URL)
+	return client
+}
+
+// counts how many requests are answered at the same time
+type Concurrency struct {
+	running, max int32
+}
+
+// handler answering every request after a while, so concurrent requests overlap
+func (c *Concurrency) Slow(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&c.running, 1)
+		for {
+			m := atomic.LoadInt32(&c.max)
+			if n <= m || atomic.CompareAndSwapInt32(&c.max, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&c.running, -1)
+		handler(w, r)
+	}
+}
+
+// the most requests that were answered at the same time
+func (c *Concurrency) Max() int {
+	return int(atomic.LoadInt32(&c.max))
+}
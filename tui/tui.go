@@ -0,0 +1,343 @@
+// Package tui implements a full screen terminal browser for netstar. all
+// requests to themoviedb run in the background so the ui never freezes.
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/themoviedb"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const help = "[yellow]enter[white] select  [yellow]tab[white] next pane  [yellow]esc[white] back  [yellow]/[white] search  [yellow]q[white] quit"
+
+type UI struct {
+	app    *tview.Application
+	client *themoviedb.Client
+
+	search   *tview.InputField
+	results  *tview.List
+	seasons  *tview.List
+	episodes *tview.List
+	detail   *tview.TextView
+	status   *tview.TextView
+
+	// the panes in the order tab walks through them
+	panes []tview.Primitive
+
+	shows  []themoviedb.TVShow
+	show   *themoviedb.TVShowDetails
+	season *themoviedb.TVSeasonDetails
+
+	// counts the requests per pane, so answers of outdated requests are dropped
+	requests map[tview.Primitive]int
+
+	// runs f on the ui goroutine and redraws the screen
+	update func(f func())
+}
+
+// creates the terminal ui. call Run to show it
+func New(client *themoviedb.Client) *UI {
+	ui := &UI{
+		app:      tview.NewApplication(),
+		client:   client,
+		search:   tview.NewInputField(),
+		results:  tview.NewList(),
+		seasons:  tview.NewList(),
+		episodes: tview.NewList(),
+		detail:   tview.NewTextView(),
+		status:   tview.NewTextView(),
+		requests: map[tview.Primitive]int{},
+	}
+	ui.update = func(f func()) {
+		ui.app.QueueUpdateDraw(f)
+	}
+	ui.panes = []tview.Primitive{ui.search, ui.results, ui.seasons, ui.episodes, ui.detail}
+
+	ui.search.SetLabel("Search: ").SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			ui.Search(ui.search.GetText())
+		}
+	})
+	ui.search.SetBorder(true)
+
+	ui.results.ShowSecondaryText(false).SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.SelectShow(index)
+	})
+	ui.results.SetBorder(true).SetTitle(" TV Shows ")
+
+	ui.seasons.ShowSecondaryText(false).SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.SelectSeason(index)
+	})
+	ui.seasons.SetBorder(true).SetTitle(" Seasons ")
+
+	ui.episodes.ShowSecondaryText(false).SetChangedFunc(func(index int, _, _ string, _ rune) {
+		ui.previewEpisode(index)
+	}).SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.SelectEpisode(index)
+	})
+	ui.episodes.SetBorder(true).SetTitle(" Episodes ")
+
+	ui.detail.SetDynamicColors(true).SetWordWrap(true)
+	ui.detail.SetBorder(true).SetTitle(" Details ")
+
+	ui.status.SetDynamicColors(true).SetText(help)
+
+	browser := tview.NewFlex().
+		AddItem(ui.results, 0, 2, false).
+		AddItem(ui.seasons, 0, 1, false).
+		AddItem(ui.episodes, 0, 2, false).
+		AddItem(ui.detail, 0, 3, false)
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ui.search, 3, 0, true).
+		AddItem(browser, 0, 1, false).
+		AddItem(ui.status, 1, 0, false)
+
+	ui.app.SetRoot(root, true).SetInputCapture(ui.handleKey)
+	return ui
+}
+
+// shows the ui and blocks until the user quits
+func (ui *UI) Run() error {
+	return ui.app.Run()
+}
+
+// global key bindings. keys typed into the search field are left alone
+func (ui *UI) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	focus := ui.app.GetFocus()
+
+	switch event.Key() {
+	case tcell.KeyTab:
+		ui.focus(ui.paneIndex(focus) + 1)
+		return nil
+	case tcell.KeyBacktab:
+		ui.focus(ui.paneIndex(focus) - 1)
+		return nil
+	case tcell.KeyEscape:
+		if focus != ui.search {
+			ui.focus(ui.paneIndex(focus) - 1)
+		}
+		return nil
+	}
+
+	if focus == ui.search {
+		return event
+	}
+
+	switch event.Rune() {
+	case 'q':
+		ui.app.Stop()
+		return nil
+	case '/':
+		ui.app.SetFocus(ui.search)
+		return nil
+	}
+	return event
+}
+
+func (ui *UI) paneIndex(p tview.Primitive) int {
+	for i, pane := range ui.panes {
+		if pane == p {
+			return i
+		}
+	}
+	return 0
+}
+
+func (ui *UI) focus(index int) {
+	index = (index + len(ui.panes)) % len(ui.panes)
+	ui.app.SetFocus(ui.panes[index])
+}
+
+func (ui *UI) setStatus(text string) {
+	if text == "" {
+		text = help
+	}
+	ui.status.SetText(text)
+}
+
+// runs fetch in the background and applies its result on the ui goroutine.
+// a newer request for the same pane wins over an older one still running
+func (ui *UI) load(pane tview.Primitive, what string, fetch func() (apply func(), err error)) {
+	ui.requests[pane]++
+	request := ui.requests[pane]
+	ui.setStatus("[yellow]loading " + tview.Escape(what) + "...")
+
+	go func() {
+		apply, err := fetch()
+		ui.update(func() {
+			if ui.requests[pane] != request {
+				return
+			}
+			if err != nil {
+				ui.setStatus("[red]" + tview.Escape(err.Error()))
+				return
+			}
+			ui.setStatus("")
+			apply()
+		})
+	}()
+}
+
+// drops everything shown in and right of pane, including requests still running
+func (ui *UI) reset(pane tview.Primitive) {
+	for i := ui.paneIndex(pane); i < len(ui.panes); i++ {
+		ui.requests[ui.panes[i]]++
+		switch p := ui.panes[i].(type) {
+		case *tview.List:
+			p.Clear()
+		case *tview.TextView:
+			p.Clear()
+		}
+	}
+}
+
+// searches tv shows and fills the result list
+func (ui *UI) Search(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+
+	ui.reset(ui.results)
+	ui.load(ui.results, "search results", func() (func(), error) {
+		results, err := ui.client.SearchTVShows(query, "1")
+		if err != nil {
+			return nil, err
+		}
+
+		return func() {
+			ui.shows = results.Results
+			for _, show := range results.Results {
+				ui.results.AddItem(tview.Escape(showTitle(show.Name, show.FirstAirDate)), "", 0, nil)
+			}
+			if len(results.Results) == 0 {
+				ui.setStatus(fmt.Sprintf("[red]no tv shows found for %q", tview.Escape(query)))
+				return
+			}
+			ui.app.SetFocus(ui.results)
+		}, nil
+	})
+}
+
+// loads the seasons of the show at index of the result list
+func (ui *UI) SelectShow(index int) {
+	if index < 0 || index >= len(ui.shows) {
+		return
+	}
+	id := strconv.Itoa(ui.shows[index].ID)
+
+	ui.reset(ui.seasons)
+	ui.load(ui.seasons, ui.shows[index].Name, func() (func(), error) {
+		show, err := ui.client.GetTVShowDetails(id)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() {
+			ui.show = show
+			for _, season := range show.Seasons {
+				ui.seasons.AddItem(tview.Escape(season.Name), "", 0, nil)
+			}
+			ui.detail.SetText(showText(show))
+			ui.app.SetFocus(ui.seasons)
+		}, nil
+	})
+}
+
+// loads the episodes of the season at index of the season list
+func (ui *UI) SelectSeason(index int) {
+	if ui.show == nil || index < 0 || index >= len(ui.show.Seasons) {
+		return
+	}
+	id := strconv.Itoa(ui.show.ID)
+	seasonNumber := strconv.Itoa(ui.show.Seasons[index].SeasonNumber)
+
+	ui.reset(ui.episodes)
+	ui.load(ui.episodes, ui.show.Seasons[index].Name, func() (func(), error) {
+		season, err := ui.client.GetSeasonDetails(id, seasonNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() {
+			ui.season = season
+			for _, episode := range season.Episodes {
+				ui.episodes.AddItem(tview.Escape(fmt.Sprintf("%d. %s", episode.EpisodeNumber, episode.Name)), "", 0, nil)
+			}
+			ui.app.SetFocus(ui.episodes)
+		}, nil
+	})
+}
+
+// loads the full details of the episode at index of the episode list
+func (ui *UI) SelectEpisode(index int) {
+	if ui.season == nil || index < 0 || index >= len(ui.season.Episodes) {
+		return
+	}
+	id := strconv.Itoa(ui.season.TVID)
+	seasonNumber := strconv.Itoa(ui.season.SeasonNumber)
+	episodeNumber := strconv.Itoa(ui.season.Episodes[index].EpisodeNumber)
+
+	ui.load(ui.detail, ui.season.Episodes[index].Name, func() (func(), error) {
+		episode, err := ui.client.GetEpisodeDetails(id, seasonNumber, episodeNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() {
+			ui.detail.SetText(episodeText(episode)).ScrollToBeginning()
+			ui.app.SetFocus(ui.detail)
+		}, nil
+	})
+}
+
+// shows what the season already knows about an episode while moving through the list
+func (ui *UI) previewEpisode(index int) {
+	if ui.season == nil || index < 0 || index >= len(ui.season.Episodes) {
+		return
+	}
+	episode := ui.season.Episodes[index]
+
+	// a running request for the full details would overwrite the preview
+	ui.requests[ui.detail]++
+	ui.detail.SetText(fmt.Sprintf("[yellow]%s[white]\nS%02dE%02d  %s\n\n%s",
+		tview.Escape(episode.Name), episode.SeasonNumber, episode.EpisodeNumber, episode.AirDate, tview.Escape(episode.Overview))).ScrollToBeginning()
+}
+
+func showTitle(name, firstAirDate string) string {
+	if len(firstAirDate) >= 4 {
+		return fmt.Sprintf("%s (%s)", name, firstAirDate[:4])
+	}
+	return name
+}
+
+func showText(show *themoviedb.TVShowDetails) string {
+	return fmt.Sprintf("[yellow]%s[white]\n%s  %d seasons  %s\n\n%s",
+		tview.Escape(show.Name), show.FirstAirDate, show.NumberOfSeasons, show.Status, tview.Escape(show.Overview))
+}
+
+func episodeText(episode *themoviedb.TVEpisodeDetails) string {
+	text := &strings.Builder{}
+	fmt.Fprintf(text, "[yellow]%s[white]\nS%02dE%02d  %s  %.1f/10\n\n%s\n",
+		tview.Escape(episode.Name), episode.SeasonNumber, episode.EpisodeNumber, episode.AirDate, episode.VoteAverage, tview.Escape(episode.Overview))
+
+	if len(episode.Crew) > 0 {
+		fmt.Fprintln(text, "\n[yellow]Crew[white]")
+		for _, member := range episode.Crew {
+			fmt.Fprintf(text, "%s (%s)\n", tview.Escape(member.Name), member.Job)
+		}
+	}
+
+	if len(episode.GuestStars) > 0 {
+		fmt.Fprintln(text, "\n[yellow]Guest Stars[white]")
+		for _, star := range episode.GuestStars {
+			fmt.Fprintf(text, "%s as %s\n", tview.Escape(star.Name), tview.Escape(star.Character))
+		}
+	}
+	return text.String()
+}
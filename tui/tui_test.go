@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bereths.com/netstar/themoviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+// creates a ui talking to a fake themoviedb api. updates of background
+// requests are handed to the test instead of the (not running) application
+func GetMockUI(t *testing.T, handler http.HandlerFunc) (*UI, chan func()) {
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+
+	client := themoviedb.NewClient(&http.Client{Timeout: 10 * time.Second}, "1234", "en-US", false)
+	client.SetBaseURL(mockServer.URL)
+
+	updates := make(chan func(), 10)
+	ui := New(client)
+	ui.update = func(f func()) {
+		updates <- f
+	}
+	return ui, updates
+}
+
+func MockTMDBHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/search/tv":
+		w.Write([]byte(`{"page":1,"results":[{"id":70523,"name":"Dark","first_air_date":"2017-12-01"}]}`))
+	case "/tv/70523":
+		w.Write([]byte(`{"id":70523,"name":"Dark","seasons":[{"season_number":1,"name":"Season 1"}]}`))
+	case "/tv/70523/season/1":
+		w.Write([]byte(`{"season_number":1,"episodes":[{"episode_number":1,"season_number":1,"name":"Secrets"},{"episode_number":3,"season_number":1,"name":"Past and Present"}]}`))
+	case "/tv/70523/season/1/episode/3":
+		w.Write([]byte(`{"episode_number":3,"season_number":1,"name":"Past and Present","guest_stars":[{"name":"Jonas","character":"Jonas Kahnwald"}]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status_code":34,"status_message":"The resource you requested could not be found."}`))
+	}
+}
+
+func TestBrowseToEpisode(t *testing.T) {
+
+	ui, updates := GetMockUI(t, MockTMDBHandler)
+
+	ui.Search("Dark")
+	(<-updates)()
+
+	assert.Equal(t, 1, ui.results.GetItemCount())
+	title, _ := ui.results.GetItemText(0)
+	assert.Equal(t, "Dark (2017)", title)
+
+	ui.SelectShow(0)
+	(<-updates)()
+
+	assert.Equal(t, 1, ui.seasons.GetItemCount())
+	assert.Contains(t, ui.detail.GetText(true), "Dark")
+
+	ui.SelectSeason(0)
+	(<-updates)()
+
+	assert.Equal(t, 2, ui.episodes.GetItemCount())
+	assert.Contains(t, ui.detail.GetText(true), "Secrets", "first episode should be previewed")
+
+	ui.SelectEpisode(1)
+	(<-updates)()
+
+	assert.Contains(t, ui.detail.GetText(true), "Jonas Kahnwald")
+}
+
+func TestOutdatedRequestsAreDropped(t *testing.T) {
+
+	ui, updates := GetMockUI(t, MockTMDBHandler)
+
+	ui.Search("Dark")
+	first := <-updates
+	ui.Search("Dark")
+	second := <-updates
+
+	// the answer of the first search arrives after the second search was started
+	first()
+	assert.Equal(t, 0, ui.results.GetItemCount(), "outdated results should be dropped")
+
+	second()
+	assert.Equal(t, 1, ui.results.GetItemCount())
+}
+
+func TestErrorsAreShownInStatusLine(t *testing.T) {
+
+	ui, updates := GetMockUI(t, MockTMDBHandler)
+
+	ui.shows = []themoviedb.TVShow{{ID: 1, Name: "Missing"}}
+	ui.SelectShow(0)
+	(<-updates)()
+
+	assert.Contains(t, ui.status.GetText(true), "could not be found")
+	assert.Equal(t, 0, ui.seasons.GetItemCount())
+}
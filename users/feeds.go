@@ -0,0 +1,79 @@
+package users
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// creates the secret token of the user's feed urls on first use. calendar apps and feed readers
+// cannot log in, so the token is the only thing protecting the feeds. like sessions only its hash
+// is stored, so an existing token cannot be shown again and "" is returned for it
+func (s *Store) FeedToken(userID int) (string, error) {
+	user, err := s.Get(userID)
+	if err != nil {
+		return "", err
+	}
+	if user.FeedTokenHash != nil {
+		return "", nil
+	}
+	return s.ResetFeedToken(userID)
+}
+
+// replaces the feed token, so urls shared by accident stop working
+func (s *Store) ResetFeedToken(userID int) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	hash := hashToken(token)
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		b := users.Get(itob(userID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		user := &User{}
+		if err := json.Unmarshal(b, user); err != nil {
+			return err
+		}
+
+		feeds := tx.Bucket(feedsBucket)
+		if user.FeedTokenHash != nil {
+			if err := feeds.Delete(user.FeedTokenHash); err != nil {
+				return err
+			}
+		}
+		user.FeedTokenHash = hash
+
+		if b, err = json.Marshal(user); err != nil {
+			return err
+		}
+		if err := users.Put(itob(userID), b); err != nil {
+			return err
+		}
+		return feeds.Put(hash, itob(userID))
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// the user the feed token belongs to
+func (s *Store) FeedUser(token string) (*User, error) {
+	var id []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(feedsBucket).Get(hashToken(token)); v != nil {
+			id = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if id == nil {
+		return nil, ErrNotFound
+	}
+	return s.Get(int(binary.BigEndian.Uint64(id)))
+}
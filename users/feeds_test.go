@@ -0,0 +1,72 @@
+package users
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestFeedToken(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+
+	token, err := store.FeedToken(user.ID)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token)
+
+	again, err := store.FeedToken(user.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, again, "an existing token cannot be shown again")
+
+	feedUser, err := store.FeedUser(token)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, feedUser.ID)
+
+	_, err = store.FeedUser("unknown token")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = store.FeedToken(99)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFeedTokenIsStoredHashed(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+	token, _ := store.FeedToken(user.ID)
+
+	store.db.View(func(tx *bolt.Tx) error {
+		tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			return b.ForEach(func(k, v []byte) error {
+				assert.False(t, bytes.Contains(k, []byte(token)), "token in key of %s", name)
+				assert.False(t, bytes.Contains(v, []byte(token)), "token in value of %s", name)
+				return nil
+			})
+		})
+		return nil
+	})
+}
+
+func TestResetFeedToken(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+	old, _ := store.FeedToken(user.ID)
+
+	token, err := store.ResetFeedToken(user.ID)
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, old, token)
+	_, err = store.FeedUser(old)
+	assert.Equal(t, ErrNotFound, err, "old urls should stop working")
+	feedUser, err := store.FeedUser(token)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, feedUser.ID)
+
+	authenticated, err := store.Authenticate("Jonas", "winter is coming")
+	assert.Nil(t, err, "password should be untouched")
+	assert.Equal(t, hashToken(token), authenticated.FeedTokenHash)
+}
@@ -0,0 +1,188 @@
+package users
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	sessionCookie = "netstar_session"
+	csrfCookie    = "netstar_csrf"
+	flashCookie   = "netstar_flash"
+
+	// how long a flash waits for the page it is meant for
+	flashDuration = 60
+
+	// the name of the hidden form field holding the csrf token
+	CSRFField = "csrf_token"
+	// the header javascript clients send the csrf token in
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// the answer to forms sent without a valid csrf token
+var ErrInvalidCSRF = errors.New("invalid csrf token, please reload the page and try again")
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	csrfKey
+)
+
+// handles logins and sessions for http handlers
+type Manager struct {
+	Store *Store
+
+	// send cookies only over https
+	SecureCookies bool
+
+	// answers requests the middleware rejects, like with a translated text.
+	// nil sends the text of err
+	Error func(w http.ResponseWriter, r *http.Request, err error, status int)
+}
+
+func NewManager(store *Store, secureCookies bool) *Manager {
+	return &Manager{Store: store, SecureCookies: secureCookies}
+}
+
+// looks up the logged in user and rejects unsafe requests without a valid csrf token.
+// CSRF uses a double submit cookie, so forms of logged out users are protected as well
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if c, err := r.Cookie(sessionCookie); err == nil {
+			if user, err := m.Store.SessionUser(c.Value); err == nil {
+				ctx = context.WithValue(ctx, userKey, user)
+			} else {
+				m.clearCookie(w, sessionCookie)
+			}
+		}
+
+		token := ""
+		if c, err := r.Cookie(csrfCookie); err == nil && len(c.Value) >= 32 {
+			token = c.Value
+		} else {
+			token, _ = randomToken()
+			http.SetCookie(w, m.cookie(csrfCookie, token))
+		}
+		ctx = context.WithValue(ctx, csrfKey, token)
+
+		if !isSafe(r.Method) && !validCSRF(r, token) {
+			m.error(w, r, ErrInvalidCSRF, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *Manager) error(w http.ResponseWriter, r *http.Request, err error, status int) {
+	if m.Error != nil {
+		m.Error(w, r, err, status)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// logs user in by setting the session cookie
+func (m *Manager) Login(w http.ResponseWriter, user *User) error {
+	token, err := m.Store.CreateSession(user.ID)
+	if err != nil {
+		return err
+	}
+
+	c := m.cookie(sessionCookie, token)
+	c.MaxAge = int(SessionDuration.Seconds())
+	http.SetCookie(w, c)
+	return nil
+}
+
+// ends the session of the request
+func (m *Manager) Logout(w http.ResponseWriter, r *http.Request) error {
+	m.clearCookie(w, sessionCookie)
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		return m.Store.DeleteSession(c.Value)
+	}
+	return nil
+}
+
+// keeps value for the next request to path, like a new secret after a form was sent.
+// handlers answering a form redirect to path so reloading the page does not send it again
+func (m *Manager) SetFlash(w http.ResponseWriter, path, value string) {
+	c := m.cookie(flashCookie, value)
+	c.Path = path
+	c.MaxAge = flashDuration
+	http.SetCookie(w, c)
+}
+
+// the value of SetFlash for the path of the request, it is only returned once
+func (m *Manager) Flash(w http.ResponseWriter, r *http.Request) string {
+	c, err := r.Cookie(flashCookie)
+	if err != nil {
+		return ""
+	}
+	clear := m.cookie(flashCookie, "")
+	clear.Path = r.URL.Path
+	clear.MaxAge = -1
+	http.SetCookie(w, clear)
+	return c.Value
+}
+
+func (m *Manager) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   m.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (m *Manager) clearCookie(w http.ResponseWriter, name string) {
+	c := m.cookie(name, "")
+	c.MaxAge = -1
+	http.SetCookie(w, c)
+}
+
+// the logged in user or nil
+func CurrentUser(r *http.Request) *User {
+	user, _ := r.Context().Value(userKey).(*User)
+	return user
+}
+
+// the token forms have to send in the CSRFField
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey).(string)
+	return token
+}
+
+// only allows redirects to paths of netstar itself, e.g. after a login
+func SafeRedirect(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.IsAbs() || u.Host != "" || !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+func isSafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func validCSRF(r *http.Request, token string) bool {
+	sent := r.Header.Get(CSRFHeader)
+	if sent == "" {
+		sent = r.PostFormValue(CSRFField)
+	}
+	return sent != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
@@ -0,0 +1,105 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GetTestHandler(m *Manager) http.Handler {
+	return m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := CurrentUser(r); user != nil {
+			w.Write([]byte(user.Username))
+		}
+	}))
+}
+
+func TestMiddlewareSetsUser(t *testing.T) {
+
+	m := NewManager(GetTestStore(t), false)
+	user, _ := m.Store.Register("Jonas", "winter is coming")
+
+	login := httptest.NewRecorder()
+	assert.Nil(t, m.Login(login, user))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	for _, c := range login.Result().Cookies() {
+		request.AddCookie(c)
+	}
+	recorder := httptest.NewRecorder()
+	GetTestHandler(m).ServeHTTP(recorder, request)
+
+	assert.Equal(t, "Jonas", recorder.Body.String())
+
+	cookies := login.Result().Cookies()
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+}
+
+func TestMiddlewareChecksCSRF(t *testing.T) {
+
+	m := NewManager(GetTestStore(t), false)
+	handler := GetTestHandler(m)
+
+	// a get request hands out the csrf cookie
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	csrf := recorder.Result().Cookies()[0]
+	assert.Equal(t, csrfCookie, csrf.Name)
+
+	post := func(token string) int {
+		form := url.Values{CSRFField: {token}}
+		request := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.AddCookie(csrf)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post(csrf.Value))
+	assert.Equal(t, http.StatusForbidden, post("forged"))
+	assert.Equal(t, http.StatusForbidden, post(""))
+
+	request := httptest.NewRequest("DELETE", "/", nil)
+	request.Header.Set(CSRFHeader, csrf.Value)
+	request.AddCookie(csrf)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code, "csrf header should be accepted")
+}
+
+func TestSafeRedirect(t *testing.T) {
+
+	assert.Equal(t, "/details?id=1", SafeRedirect("/details?id=1"))
+	assert.Equal(t, "/", SafeRedirect("https://evil.com"))
+	assert.Equal(t, "/", SafeRedirect("//evil.com"))
+	assert.Equal(t, "/", SafeRedirect("/\\evil.com"))
+	assert.Equal(t, "/", SafeRedirect(""))
+}
+
+func TestFlash(t *testing.T) {
+
+	m := NewManager(GetTestStore(t), false)
+
+	set := httptest.NewRecorder()
+	m.SetFlash(set, "/calendar", "secret")
+	cookie := set.Result().Cookies()[0]
+	assert.Equal(t, "/calendar", cookie.Path)
+	assert.True(t, cookie.HttpOnly)
+	assert.Greater(t, cookie.MaxAge, 0)
+
+	request := httptest.NewRequest("GET", "/calendar", nil)
+	request.AddCookie(cookie)
+	recorder := httptest.NewRecorder()
+	assert.Equal(t, "secret", m.Flash(recorder, request))
+	cleared := recorder.Result().Cookies()[0]
+	assert.Equal(t, "/calendar", cleared.Path)
+	assert.Less(t, cleared.MaxAge, 0, "a flash should only be shown once")
+
+	assert.Equal(t, "", m.Flash(httptest.NewRecorder(), httptest.NewRequest("GET", "/calendar", nil)))
+}
@@ -0,0 +1,73 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// how long a login lasts
+const SessionDuration = 30 * 24 * time.Hour
+
+// creates a session for the user and returns its token. only a hash of the
+// token is stored, so a leaked database does not leak sessions
+func (s *Store) CreateSession(userID int) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value, uint64(userID))
+	binary.BigEndian.PutUint64(value[8:], uint64(time.Now().Add(SessionDuration).Unix()))
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put(hashToken(token), value)
+	})
+	return token, err
+}
+
+// returns the user logged in with token
+func (s *Store) SessionUser(token string) (*User, error) {
+	var value []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(sessionsBucket).Get(hashToken(token)); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if len(value) != 16 {
+		return nil, ErrNotFound
+	}
+
+	expires := time.Unix(int64(binary.BigEndian.Uint64(value[8:])), 0)
+	if time.Now().After(expires) {
+		s.DeleteSession(token)
+		return nil, ErrNotFound
+	}
+	return s.Get(int(binary.BigEndian.Uint64(value)))
+}
+
+// logs the session out
+func (s *Store) DeleteSession(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete(hashToken(token))
+	})
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,48 @@
+package users
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSessions(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+
+	token, err := store.CreateSession(user.ID)
+	assert.Nil(t, err)
+
+	sessionUser, err := store.SessionUser(token)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, sessionUser.ID)
+
+	_, err = store.SessionUser("unknown token")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Nil(t, store.DeleteSession(token))
+	_, err = store.SessionUser(token)
+	assert.Equal(t, ErrNotFound, err, "deleted session should be logged out")
+}
+
+func TestExpiredSession(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+	token, _ := store.CreateSession(user.ID)
+
+	// let the session expire
+	store.db.Update(func(tx *bolt.Tx) error {
+		value := make([]byte, 16)
+		binary.BigEndian.PutUint64(value, uint64(user.ID))
+		binary.BigEndian.PutUint64(value[8:], uint64(time.Now().Add(-time.Minute).Unix()))
+		return tx.Bucket(sessionsBucket).Put(hashToken(token), value)
+	})
+
+	_, err := store.SessionUser(token)
+	assert.Equal(t, ErrNotFound, err)
+}
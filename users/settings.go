@@ -0,0 +1,48 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var ErrInvalidRegion = errors.New("region must be a country code like DE or US")
+
+var validRegion = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// sets the country the user watches from, like DE. an empty region goes back to the default of the server
+func (s *Store) SetRegion(userID int, region string) error {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region != "" && !validRegion.MatchString(region) {
+		return ErrInvalidRegion
+	}
+	return s.update(userID, func(user *User) {
+		user.Region = region
+	})
+}
+
+// changes the saved user in one transaction
+func (s *Store) update(userID int, change func(user *User)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		b := users.Get(itob(userID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		user := &User{}
+		if err := json.Unmarshal(b, user); err != nil {
+			return err
+		}
+		change(user)
+
+		b, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		return users.Put(itob(userID), b)
+	})
+}
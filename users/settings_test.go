@@ -0,0 +1,24 @@
+package users
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRegion(t *testing.T) {
+
+	store := GetTestStore(t)
+	user, _ := store.Register("Jonas", "winter is coming")
+
+	assert.Nil(t, store.SetRegion(user.ID, " de "))
+	user, _ = store.Get(user.ID)
+	assert.Equal(t, "DE", user.Region)
+
+	assert.Equal(t, ErrInvalidRegion, store.SetRegion(user.ID, "Germany"))
+	assert.Equal(t, ErrNotFound, store.SetRegion(42, "US"))
+
+	assert.Nil(t, store.SetRegion(user.ID, ""))
+	user, _ = store.Get(user.ID)
+	assert.Equal(t, "", user.Region, "an empty region should remove the override")
+}
@@ -0,0 +1,193 @@
+// Package users stores the accounts of netstar and keeps them logged in with
+// cookie sessions. forms are protected against cross site request forgery.
+package users
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	usersBucket     = []byte("users")
+	usernamesBucket = []byte("usernames")
+	sessionsBucket  = []byte("sessions")
+	feedsBucket     = []byte("feeds")
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrNotFound           = errors.New("user not found")
+	ErrInvalidUsername    = errors.New("username must be 3 to 32 letters, digits, dots, dashes or underscores")
+	ErrPasswordTooShort   = fmt.Errorf("password must be at least %d characters long", minPasswordLength)
+)
+
+const minPasswordLength = 8
+
+var validUsername = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash []byte    `json:"password_hash"`
+	Created      time.Time `json:"created"`
+	// the hash of the secret in the urls of the user's calendar and feeds
+	FeedTokenHash []byte `json:"feed_token_hash,omitempty"`
+	// the country the user watches from, overrides the region of the server
+	Region string `json:"region,omitempty"`
+}
+
+// the accounts and sessions, stored in a bolt database shared with the other parts of netstar
+type Store struct {
+	db *bolt.DB
+}
+
+// creates the buckets of the store if they do not exist yet
+func NewStore(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, usernamesBucket, sessionsBucket, feedsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// validates username and password and creates a new user
+func (s *Store) Register(username, password string) (*User, error) {
+	username = strings.TrimSpace(username)
+	if !validUsername.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	if len(password) < minPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{Username: username, PasswordHash: hash, Created: time.Now().UTC()}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		names := tx.Bucket(usernamesBucket)
+		key := []byte(strings.ToLower(username))
+		if names.Get(key) != nil {
+			return ErrUsernameTaken
+		}
+
+		bucket := tx.Bucket(usersBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		user.ID = int(id)
+
+		b, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(itob(user.ID), b); err != nil {
+			return err
+		}
+		return names.Put(key, itob(user.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// returns the user if the password is right
+func (s *Store) Authenticate(username, password string) (*User, error) {
+	var id []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(usernamesBucket).Get([]byte(strings.ToLower(strings.TrimSpace(username)))); v != nil {
+			id = append([]byte(nil), v...)
+		}
+		return nil
+	})
+
+	if id == nil {
+		// compare anyway, so unknown usernames take as long as wrong passwords
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.Get(int(binary.BigEndian.Uint64(id)))
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("netstar"), bcrypt.DefaultCost)
+
+func (s *Store) Get(id int) (*User, error) {
+	user := &User{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket).Get(itob(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(b, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// the user with the username, in any case
+func (s *Store) ByName(username string) (*User, error) {
+	var id []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(usernamesBucket).Get([]byte(strings.ToLower(strings.TrimSpace(username)))); v != nil {
+			id = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if id == nil {
+		return nil, ErrNotFound
+	}
+	return s.Get(int(binary.BigEndian.Uint64(id)))
+}
+
+// all users ordered by id
+func (s *Store) All() ([]User, error) {
+	var users []User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, v []byte) error {
+			var user User
+			if err := json.Unmarshal(v, &user); err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+	})
+	return users, err
+}
+
+// bolt keys sort bytewise, so ids are stored big endian
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
@@ -0,0 +1,113 @@
+package users
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestRegisterAndAuthenticate(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	user, err := store.Register("Jonas", "winter is coming")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.NotContains(t, string(user.PasswordHash), "winter", "password should be hashed")
+
+	user, err = store.Authenticate("jonas", "winter is coming")
+	assert.Nil(t, err, "usernames should be case insensitive")
+	assert.Equal(t, "Jonas", user.Username)
+
+	_, err = store.Authenticate("Jonas", "wrong password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	_, err = store.Authenticate("Martha", "winter is coming")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestByName(t *testing.T) {
+
+	store := GetTestStore(t)
+	store.Register("Jonas", "winter is coming")
+
+	user, err := store.ByName(" JONAS ")
+	assert.Nil(t, err)
+	assert.Equal(t, "Jonas", user.Username)
+
+	_, err = store.ByName("Martha")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+// the user ids of usernames used to be read from the memory of bolt after the transaction
+// had ended, which other writes may have reused or unmapped by then
+func TestLookupsWhileOthersWrite(t *testing.T) {
+
+	store := GetTestStore(t)
+	jonas, _ := store.Register("Jonas", "winter is coming")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			store.CreateSession(jonas.ID)
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		user, err := store.ByName("Jonas")
+		if assert.Nil(t, err) {
+			assert.Equal(t, jonas.ID, user.ID)
+		}
+	}
+	user, err := store.Authenticate("Jonas", "winter is coming")
+	if assert.Nil(t, err) {
+		assert.Equal(t, jonas.ID, user.ID)
+	}
+	<-done
+
+	user, err = store.ByName("Jonas")
+	if assert.Nil(t, err) {
+		assert.Equal(t, jonas.ID, user.ID)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+
+	store := GetTestStore(t)
+	store.Register("Jonas", "winter is coming")
+
+	_, err := store.Register("JONAS", "another password")
+	assert.Equal(t, ErrUsernameTaken, err)
+
+	_, err = store.Register("a", "winter is coming")
+	assert.NotNil(t, err, "too short username should fail")
+
+	_, err = store.Register("Martha Nielsen", "winter is coming")
+	assert.NotNil(t, err, "spaces should not be allowed")
+
+	_, err = store.Register("Martha", "short")
+	assert.NotNil(t, err, "too short password should fail")
+
+	users, err := store.All()
+	assert.Nil(t, err)
+	assert.Len(t, users, 1)
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"bereths.com/netstar/themoviedb"
+)
+
+// the trailers of a show or season
+func loadVideos(videos *themoviedb.Videos, err error) []themoviedb.Video {
+	if !fetched(err, "videos") {
+		return nil
+	}
+	return videos.Trailers()
+}
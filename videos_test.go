@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetailsShowClickToLoadVideos(t *testing.T) {
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/70523":
+			w.Write([]byte(`{"id":70523,"name":"Dark"}`))
+		case "/tv/70523/videos":
+			w.Write([]byte(`{"results":[{"iso_639_1":"de","key":"abc","name":"Offizieller Trailer","site":"YouTube","type":"Trailer"},{"key":"xyz","name":"Bloopers","site":"YouTube","type":"Bloopers"}]}`))
+		case "/tv/70523/season/1":
+			w.Write([]byte(`{"name":"Staffel 1","season_number":1}`))
+		case "/tv/70523/season/1/videos":
+			w.Write([]byte(`{"results":[{"iso_639_1":"en","key":"s1","name":"Season 1 Teaser","site":"Vimeo","type":"Teaser"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer tmdbServer.Close()
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(tmdbServer.URL)
+	mockServer := httptest.NewServer(NewAppRouter(&App{TMDB: themoviedbAPI}))
+	defer mockServer.Close()
+
+	resp, _ := http.Get(mockServer.URL + "/details?id=70523")
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, `data-src="https://www.youtube-nocookie.com/embed/abc?autoplay=1"`)
+	assert.Contains(t, body, "Offizieller Trailer")
+	assert.NotContains(t, body, "Bloopers")
+	assert.NotContains(t, body, "<iframe", "players should only be loaded on click")
+
+	resp, _ = http.Get(mockServer.URL + "/details/season?id=70523&seasonNumber=1")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, `data-src="https://player.vimeo.com/video/s1?autoplay=1"`)
+}
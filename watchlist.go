@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/users"
+	"bereths.com/netstar/watchlist"
+	"github.com/gorilla/mux"
+)
+
+// a saved show refreshed with what themoviedb currently knows about it
+type WatchlistItem struct {
+	watchlist.Entry
+	Status      string `json:"status"`
+	NextAirDate string `json:"next_air_date,omitempty"`
+}
+
+// the saved shows and the secret urls of the feed of their new episodes,
+// empty unless the urls were created just now
+type WatchlistPage struct {
+	Items   []WatchlistItem
+	AtomURL string
+	RSSURL  string
+}
+
+// shows the watchlist of the logged in user
+func WatchlistPageHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, manager *users.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		items, err := watchlistItems(themoviedbAPI, wl, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		token, err := feedToken(w, r, manager, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		page := &WatchlistPage{Items: items}
+		if token != "" {
+			page.AtomURL = absoluteURL(r, "/feeds/watchlist/"+token+".atom")
+			page.RSSURL = absoluteURL(r, "/feeds/watchlist/"+token+".rss")
+		}
+		render(w, r, watchlistPage, page)
+	}
+}
+
+// adds the show of the form to the watchlist and goes back to its details
+func AddToWatchlistHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		id := r.PostFormValue("id")
+		if _, err := addToWatchlist(themoviedbAPI, wl, user.ID, id); err != nil {
+			httpError(w, r, err, errorStatus(err))
+			return
+		}
+
+		http.Redirect(w, r, "/details?id="+url.QueryEscape(id), http.StatusSeeOther)
+	}
+}
+
+// removes the show of the form from the watchlist
+func RemoveFromWatchlistHandler(wl *watchlist.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		id, err := strconv.Atoi(r.PostFormValue("id"))
+		if err != nil {
+			httpError(w, r, errInvalidID, http.StatusBadRequest)
+			return
+		}
+
+		if err := wl.Remove(user.ID, id); err != nil && err != watchlist.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		next := r.PostFormValue("next")
+		if next == "" {
+			next = "/watchlist"
+		}
+		http.Redirect(w, r, users.SafeRedirect(next), http.StatusSeeOther)
+	}
+}
+
+// GET /api/watchlist
+func APIWatchlistHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			writeJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		items, err := watchlistItems(themoviedbAPI, wl, user.ID)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, items)
+	}
+}
+
+// PUT /api/watchlist/{id}
+func APIAddToWatchlistHandler(themoviedbAPI *themoviedb.Client, wl *watchlist.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			writeJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		entry, err := addToWatchlist(themoviedbAPI, wl, user.ID, mux.Vars(r)["id"])
+		if err != nil {
+			writeJSONError(w, errorStatus(err), err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entry)
+	}
+}
+
+// DELETE /api/watchlist/{id}
+func APIRemoveFromWatchlistHandler(wl *watchlist.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.CurrentUser(r)
+		if user == nil {
+			writeJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid show id")
+			return
+		}
+
+		err = wl.Remove(user.ID, id)
+		if err == watchlist.ErrNotFound {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// looks the show up so only existing shows are saved, with their current name and poster
+func addToWatchlist(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, userID int, id string) (*watchlist.Entry, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, errInvalidID
+	}
+
+	show, err := themoviedbAPI.GetTVShowDetails(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := watchlist.Entry{ShowID: show.ID, Name: show.Name, PosterPath: show.PosterPath}
+	if err := wl.Add(userID, entry); err != nil {
+		return nil, err
+	}
+
+	log.Println("Added to watchlist: ", show.Name)
+	return &entry, nil
+}
+
+// the watchlist of user with status and next air date of every show. shows are
+// refreshed concurrently; if themoviedb fails the saved entry is shown as is
+func watchlistItems(themoviedbAPI *themoviedb.Client, wl *watchlist.Store, userID int) ([]WatchlistItem, error) {
+	entries, err := wl.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]WatchlistItem, len(entries))
+	themoviedb.Parallel(len(entries), func(i int) {
+		item := &items[i]
+		item.Entry = entries[i]
+		show, err := themoviedbAPI.GetTVShowDetails(strconv.Itoa(item.ShowID))
+		if err != nil {
+			log.Printf("Could not refresh %s: %v", item.Name, err)
+			return
+		}
+		item.Name, item.PosterPath = show.Name, show.PosterPath
+		item.Status = show.Status
+		if show.NextEpisodeToAir != nil {
+			item.NextAirDate = show.NextEpisodeToAir.AirDate
+		}
+	})
+
+	return items, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
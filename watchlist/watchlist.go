@@ -0,0 +1,135 @@
+// Package watchlist stores the tv shows every user wants to watch.
+package watchlist
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var watchlistBucket = []byte("watchlist")
+
+var ErrNotFound = errors.New("show is not on the watchlist")
+
+// a saved show. name and poster are kept so the list can be shown without asking themoviedb
+type Entry struct {
+	ShowID     int       `json:"show_id"`
+	Name       string    `json:"name"`
+	PosterPath string    `json:"poster_path"`
+	Added      time.Time `json:"added"`
+}
+
+// the watchlists of all users in the bolt database. every user has a nested bucket keyed by show id
+type Store struct {
+	db *bolt.DB
+}
+
+func NewStore(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(watchlistBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// saves the show for user. adding a show twice keeps the date it was first added
+func (s *Store) Add(userID int, entry Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(watchlistBucket).CreateBucketIfNotExists(itob(userID))
+		if err != nil {
+			return err
+		}
+
+		if b := bucket.Get(itob(entry.ShowID)); b != nil {
+			var old Entry
+			if err := json.Unmarshal(b, &old); err == nil {
+				entry.Added = old.Added
+			}
+		}
+		if entry.Added.IsZero() {
+			entry.Added = time.Now().UTC()
+		}
+
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(itob(entry.ShowID), b)
+	})
+}
+
+func (s *Store) Remove(userID, showID int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(watchlistBucket).Bucket(itob(userID))
+		if bucket == nil || bucket.Get(itob(showID)) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete(itob(showID))
+	})
+}
+
+func (s *Store) Has(userID, showID int) bool {
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(watchlistBucket).Bucket(itob(userID)); bucket != nil {
+			found = bucket.Get(itob(showID)) != nil
+		}
+		return nil
+	})
+	return found
+}
+
+// the watchlist of user, the latest added show first
+func (s *Store) List(userID int) ([]Entry, error) {
+	entries := []Entry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(watchlistBucket).Bucket(itob(userID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Added.After(entries[j].Added) })
+	return entries, err
+}
+
+// the shows on any watchlist, ordered by id
+func (s *Store) Shows() ([]int, error) {
+	seen := map[int]bool{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(watchlistBucket).ForEach(func(user, _ []byte) error {
+			return tx.Bucket(watchlistBucket).Bucket(user).ForEach(func(k, _ []byte) error {
+				seen[int(binary.BigEndian.Uint64(k))] = true
+				return nil
+			})
+		})
+	})
+
+	shows := make([]int, 0, len(seen))
+	for id := range seen {
+		shows = append(shows, id)
+	}
+	sort.Ints(shows)
+	return shows, err
+}
+
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
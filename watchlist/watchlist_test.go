@@ -0,0 +1,81 @@
+package watchlist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func GetTestStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "netstar.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestAddAndList(t *testing.T) {
+
+	store := GetTestStore(t)
+
+	assert.Nil(t, store.Add(1, Entry{ShowID: 70523, Name: "Dark", Added: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}))
+	assert.Nil(t, store.Add(1, Entry{ShowID: 1399, Name: "Game of Thrones"}))
+	assert.Nil(t, store.Add(2, Entry{ShowID: 66732, Name: "Stranger Things"}))
+
+	entries, err := store.List(1)
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2, "watchlists should be separated by user")
+	assert.Equal(t, "Game of Thrones", entries[0].Name, "latest added show should come first")
+	assert.True(t, store.Has(1, 70523))
+	assert.False(t, store.Has(2, 70523))
+}
+
+func TestAddTwiceKeepsDate(t *testing.T) {
+
+	store := GetTestStore(t)
+	added := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store.Add(1, Entry{ShowID: 70523, Name: "Dark", Added: added})
+	store.Add(1, Entry{ShowID: 70523, Name: "Dark"})
+
+	entries, _ := store.List(1)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, added, entries[0].Added)
+}
+
+func TestRemove(t *testing.T) {
+
+	store := GetTestStore(t)
+	store.Add(1, Entry{ShowID: 70523, Name: "Dark"})
+
+	assert.Nil(t, store.Remove(1, 70523))
+	assert.Equal(t, ErrNotFound, store.Remove(1, 70523))
+	assert.Equal(t, ErrNotFound, store.Remove(2, 70523), "user without watchlist")
+
+	entries, err := store.List(1)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShows(t *testing.T) {
+
+	store := GetTestStore(t)
+	store.Add(1, Entry{ShowID: 70523, Name: "Dark"})
+	store.Add(2, Entry{ShowID: 70523, Name: "Dark"})
+	store.Add(2, Entry{ShowID: 1399, Name: "Game of Thrones"})
+
+	shows, err := store.Shows()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1399, 70523}, shows)
+}
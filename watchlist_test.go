@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bereths.com/netstar/themoviedb"
+	"bereths.com/netstar/themoviedb/themoviedbtest"
+	"bereths.com/netstar/watchlist"
+	"github.com/stretchr/testify/assert"
+)
+
+// a themoviedb answering every show request with dark
+func GetMockShowClient(t *testing.T) *httptest.Server {
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tv/70523" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status_code":34,"status_message":"The resource you requested could not be found."}`))
+			return
+		}
+		w.Write([]byte(`{"id":70523,"name":"Dark","poster_path":"/dark.jpg","status":"Ended","next_episode_to_air":null}`))
+	}))
+	t.Cleanup(tmdbServer.Close)
+	return tmdbServer
+}
+
+// answers every request of themoviedb with dark
+func DarkHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"id":70523,"name":"Dark","status":"Ended"}`))
+}
+
+// sends a json api request with the csrf header
+func DoAPIRequest(t *testing.T, client *http.Client, method, target, token string) *http.Response {
+	req, _ := http.NewRequest(method, target, nil)
+	req.Header.Set("X-CSRF-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestWatchlistAPI(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockShowClient(t).URL)
+	mockServer, client, _ := GetAccountServer(t, themoviedbAPI)
+
+	resp := DoAPIRequest(t, client, "GET", mockServer.URL+"/api/watchlist", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/details?id=70523")
+
+	resp = DoAPIRequest(t, client, "PUT", mockServer.URL+"/api/watchlist/70523", token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = DoAPIRequest(t, client, "PUT", mockServer.URL+"/api/watchlist/1", token)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unknown shows should not be saved")
+
+	resp = DoAPIRequest(t, client, "GET", mockServer.URL+"/api/watchlist", "")
+	var items []WatchlistItem
+	json.NewDecoder(resp.Body).Decode(&items)
+	resp.Body.Close()
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "Dark", items[0].Name)
+		assert.Equal(t, "Ended", items[0].Status)
+	}
+
+	resp = DoAPIRequest(t, client, "DELETE", mockServer.URL+"/api/watchlist/70523", token)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = DoAPIRequest(t, client, "DELETE", mockServer.URL+"/api/watchlist/70523", token)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWatchlistPage(t *testing.T) {
+
+	themoviedbAPI := GetValidClient()
+	themoviedbAPI.SetBaseURL(GetMockShowClient(t).URL)
+	mockServer, client, app := GetAccountServer(t, themoviedbAPI)
+
+	resp, _ := client.Get(mockServer.URL + "/watchlist")
+	assert.Contains(t, resp.Request.URL.String(), "/login?next=%2Fwatchlist", "should redirect to login")
+	resp.Body.Close()
+
+	LoginTestUser(t, mockServer, client)
+	token := GetCSRFToken(t, client, mockServer.URL+"/details?id=70523")
+
+	resp, _ = client.PostForm(mockServer.URL+"/watchlist", map[string][]string{"csrf_token": {token}, "id": {"70523"}})
+	body := ReadBody(t, resp)
+	assert.Contains(t, body, "Remove from watchlist", "details should offer to remove the saved show")
+
+	users, _ := app.Users.Store.All()
+	assert.True(t, app.Watchlist.Has(users[0].ID, 70523))
+
+	resp, _ = client.Get(mockServer.URL + "/watchlist")
+	body = ReadBody(t, resp)
+	assert.Contains(t, body, "Dark")
+	assert.Contains(t, body, "Ended")
+	assert.Contains(t, body, "No upcoming episode")
+}
+
+func TestWatchlistItemsBoundConcurrency(t *testing.T) {
+
+	var concurrency themoviedbtest.Concurrency
+	themoviedbAPI := themoviedbtest.NewClient(t, concurrency.Slow(DarkHandler))
+	wl, _ := watchlist.NewStore(GetTestDB(t))
+	for id := 1; id <= 30; id++ {
+		wl.Add(1, watchlist.Entry{ShowID: id})
+	}
+
+	items, err := watchlistItems(themoviedbAPI, wl, 1)
+
+	assert.Nil(t, err)
+	assert.Len(t, items, 30)
+	assert.LessOrEqual(t, concurrency.Max(), themoviedb.MaxConcurrentRequests)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"bereths.com/netstar/notify"
+	"bereths.com/netstar/users"
+)
+
+// GET /api/webhooks/deliveries?limit=50, the latest webhook deliveries first with the urls cut after the host
+func APIDeliveriesHandler(store *notify.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if users.CurrentUser(r) == nil {
+			writeJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeJSONError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			limit = n
+		}
+
+		deliveries, err := store.Deliveries(limit)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		for i := range deliveries {
+			redacted := redactURL(deliveries[i].URL)
+			deliveries[i].Error = strings.ReplaceAll(deliveries[i].Error, deliveries[i].URL, redacted)
+			deliveries[i].URL = redacted
+		}
+
+		writeJSON(w, http.StatusOK, deliveries)
+	}
+}
+
+// the scheme and host of a webhook url. every user can read the log, the rest of the url often holds a token
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "redacted"
+	}
+	return u.Scheme + "://" + u.Host + "/redacted"
+}